@@ -0,0 +1,176 @@
+package alerts
+
+import (
+	"encoding/json"
+	"net/netip"
+	"sort"
+	"strings"
+)
+
+// Alert is a shodan network alert as listed by /shodan/alert/info
+type Alert struct {
+	Id      string `json:"id"`
+	Name    string `json:"name"`
+	Filters struct {
+		Ip []string `json:"ip"`
+	} `json:"filters"`
+	Triggers map[string]json.RawMessage `json:"triggers"`
+
+	prefixes []netip.Prefix
+}
+
+// the names of the triggers enabled on the alert, sorted
+func (a *Alert) TriggerNames() []string {
+	names := []string{}
+	for name := range a.Triggers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// AlertIndex holds every network alert on the account so feed items can be
+// matched to the alert that covers their ip
+type AlertIndex struct {
+	Alerts []*Alert
+}
+
+// lists the network alerts once, every feed item is matched against this list
+func DownloadAlerts(client ShodanClient) (*AlertIndex, error) {
+	body, err := client.Alerts()
+	if err != nil {
+		return nil, err
+	}
+
+	alerts := []*Alert{}
+	if err := json.Unmarshal(body, &alerts); err != nil {
+		return nil, err
+	}
+
+	for _, alert := range alerts {
+		alert.prefixes = parseFilterIps(alert.Filters.Ip)
+	}
+
+	return &AlertIndex{Alerts: alerts}, nil
+}
+
+// Match finds the alert covering the ip. When alerts overlap the one with the
+// trigger enabled wins, and then the one with the narrowest range. Feed items
+// matching several triggers list them separated by commas, any one of them counts
+func (a *AlertIndex) Match(ip string, trigger string) *Alert {
+	addr, err := netip.ParseAddr(ip)
+	if err != nil {
+		return nil
+	}
+
+	var best *Alert
+	bestBits := -1
+	bestTrigger := false
+
+	for _, alert := range a.Alerts {
+		hasTrigger := false
+		for _, name := range strings.Split(trigger, ",") {
+			if _, ok := alert.Triggers[strings.TrimSpace(name)]; ok {
+				hasTrigger = true
+			}
+		}
+		for _, prefix := range alert.prefixes {
+			if !prefix.Contains(addr) {
+				continue
+			}
+			if (hasTrigger && !bestTrigger) || (hasTrigger == bestTrigger && prefix.Bits() > bestBits) {
+				best = alert
+				bestBits = prefix.Bits()
+				bestTrigger = hasTrigger
+			}
+		}
+	}
+
+	return best
+}
+
+// filters hold single ips and cidr blocks, single ips are treated as a full length prefix
+func parseFilterIps(filters []string) []netip.Prefix {
+	prefixes := []netip.Prefix{}
+
+	for _, filter := range filters {
+		filter = strings.TrimSpace(filter)
+		if strings.Contains(filter, "/") {
+			prefix, err := netip.ParsePrefix(filter)
+			if err == nil {
+				prefixes = append(prefixes, prefix.Masked())
+			}
+			continue
+		}
+
+		addr, err := netip.ParseAddr(filter)
+		if err == nil {
+			prefixes = append(prefixes, netip.PrefixFrom(addr, addr.BitLen()))
+		}
+	}
+
+	return prefixes
+}
+
+type Trigger struct {
+	Name        string `json:"name"`
+	Rule        string `json:"rule"`
+	Description string `json:"description"`
+}
+
+func DownloadTriggers(client ShodanClient) ([]Trigger, error) {
+	body, err := client.Triggers()
+	if err != nil {
+		return nil, err
+	}
+
+	triggers := []Trigger{}
+	err = json.Unmarshal(body, &triggers)
+	sort.Slice(triggers, func(i, j int) bool {
+		return triggers[i].Name < triggers[j].Name
+	})
+
+	return triggers, err
+}
+
+type alertDefinition struct {
+	Name    string `json:"name,omitempty"`
+	Filters struct {
+		Ip []string `json:"ip"`
+	} `json:"filters"`
+}
+
+// creates a network alert watching the ranges and enables each trigger on it
+func CreateAlert(client ShodanClient, name string, ranges []string, triggers []string) (*Alert, error) {
+	definition := alertDefinition{Name: name}
+	definition.Filters.Ip = ranges
+
+	body, _ := json.Marshal(definition)
+	response, err := client.CreateAlert(body)
+	if err != nil {
+		return nil, err
+	}
+
+	alert := &Alert{}
+	if err := json.Unmarshal(response, alert); err != nil {
+		return nil, err
+	}
+
+	for _, trigger := range triggers {
+		if err := client.EnableTrigger(alert.Id, trigger); err != nil {
+			return alert, err
+		}
+	}
+
+	return alert, nil
+}
+
+// replaces the ranges watched by an alert
+func UpdateAlert(client ShodanClient, id string, ranges []string) error {
+	definition := alertDefinition{}
+	definition.Filters.Ip = ranges
+
+	body, _ := json.Marshal(definition)
+	_, err := client.UpdateAlert(id, body)
+	return err
+}
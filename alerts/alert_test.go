@@ -0,0 +1,48 @@
+package alerts
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestAlertIndexMatch(t *testing.T) {
+	alerts := []*Alert{}
+	json.Unmarshal([]byte(`[
+		{"id": "WIDE", "filters": {"ip": ["198.51.0.0/16"]}, "triggers": {"open_database": {}}},
+		{"id": "NARROW", "filters": {"ip": ["198.51.100.0/24"]}, "triggers": {"end_of_life": {}}},
+		{"id": "HOST", "filters": {"ip": ["198.51.100.7"]}, "triggers": {}}
+	]`), &alerts)
+	for _, alert := range alerts {
+		alert.prefixes = parseFilterIps(alert.Filters.Ip)
+	}
+	index := &AlertIndex{Alerts: alerts}
+
+	tests := []struct {
+		ip      string
+		trigger string
+		want    string
+	}{
+		// the narrowest alert wins when none has the trigger
+		{"198.51.100.7", "malware", "HOST"},
+		{"198.51.100.8", "malware", "NARROW"},
+		{"198.51.7.1", "malware", "WIDE"},
+		// an alert with the trigger beats a narrower one without it
+		{"198.51.100.7", "open_database", "WIDE"},
+		{"198.51.100.7", "end_of_life", "NARROW"},
+		// any trigger of a comma separated list counts
+		{"198.51.100.7", "malware,open_database", "WIDE"},
+		{"198.51.100.7", "open_database, end_of_life", "NARROW"},
+		{"192.0.2.1", "open_database", ""},
+		{"not an ip", "open_database", ""},
+	}
+
+	for _, test := range tests {
+		got := ""
+		if alert := index.Match(test.ip, test.trigger); alert != nil {
+			got = alert.Id
+		}
+		if got != test.want {
+			t.Errorf("Match(%q, %q) = %q, want %q", test.ip, test.trigger, got, test.want)
+		}
+	}
+}
@@ -0,0 +1,183 @@
+package alerts
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	DefaultApiUrl     = "https://api.shodan.io"
+	DefaultMonitorUrl = "https://monitor.shodan.io"
+	DefaultStreamUrl  = "https://stream.shodan.io"
+)
+
+// ShodanClient is every call the tool makes against Shodan. Each method returns
+// the raw response body so callers can decode it however they need.
+type ShodanClient interface {
+	Rss() ([]byte, error)
+	Alerts() ([]byte, error)
+	Host(ip string) ([]byte, error)
+	Search(query string, page int) ([]byte, error)
+	// the alert stream stays open, closing the reader ends it
+	Stream(ctx context.Context) (io.ReadCloser, error)
+
+	// network alert management, body is the json alert definition
+	Triggers() ([]byte, error)
+	CreateAlert(body []byte) ([]byte, error)
+	UpdateAlert(id string, body []byte) ([]byte, error)
+	DeleteAlert(id string) error
+	EnableTrigger(id string, trigger string) error
+	DisableTrigger(id string, trigger string) error
+}
+
+type HttpClient struct {
+	ApiUrl     string
+	MonitorUrl string
+	StreamUrl  string
+	Key        string
+	http       *http.Client
+	stream     *http.Client
+}
+
+func NewHttpClient(apiUrl string, monitorUrl string, streamUrl string, key string) *HttpClient {
+	if apiUrl == "" {
+		apiUrl = DefaultApiUrl
+	}
+	if monitorUrl == "" {
+		monitorUrl = DefaultMonitorUrl
+	}
+	if streamUrl == "" {
+		streamUrl = DefaultStreamUrl
+	}
+
+	return &HttpClient{
+		ApiUrl:     apiUrl,
+		MonitorUrl: monitorUrl,
+		StreamUrl:  streamUrl,
+		Key:        key,
+		http:       &http.Client{Timeout: 60 * time.Second},
+		stream:     &http.Client{},
+	}
+}
+
+// reads the base urls and key from the environment, falling back to the live shodan api
+func NewClientFromEnv() *HttpClient {
+	return NewHttpClient(os.Getenv("SHODAN_API_URL"), os.Getenv("SHODAN_MONITOR_URL"), os.Getenv("SHODAN_STREAM_URL"), os.Getenv("API_KEY"))
+}
+
+func (c *HttpClient) Rss() ([]byte, error) {
+	return c.get(c.MonitorUrl+"/events.rss", url.Values{})
+}
+
+// lists every network alert on the account
+func (c *HttpClient) Alerts() ([]byte, error) {
+	return c.get(c.ApiUrl+"/shodan/alert/info", url.Values{})
+}
+
+func (c *HttpClient) Host(ip string) ([]byte, error) {
+	return c.get(c.ApiUrl+"/shodan/host/"+url.PathEscape(ip), url.Values{})
+}
+
+func (c *HttpClient) Search(query string, page int) ([]byte, error) {
+	return c.get(c.ApiUrl+"/shodan/host/search", url.Values{"query": {query}, "page": {strconv.Itoa(page)}})
+}
+
+// opens the stream of banners for every network alert on the account
+func (c *HttpClient) Stream(ctx context.Context) (io.ReadCloser, error) {
+	endpoint := c.StreamUrl + "/shodan/alert?" + url.Values{"key": {c.Key}}.Encode()
+	request, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	response, err := SharedLimiter().Do(func() (*http.Response, error) {
+		return c.stream.Do(request)
+	})
+	if err != nil {
+		return nil, err
+	}
+	if response.StatusCode != http.StatusOK {
+		response.Body.Close()
+		return nil, &StatusError{Code: response.StatusCode, Status: response.Status}
+	}
+
+	return response.Body, nil
+}
+
+// lists the triggers that can be enabled on a network alert
+func (c *HttpClient) Triggers() ([]byte, error) {
+	return c.get(c.ApiUrl+"/shodan/alert/triggers", url.Values{})
+}
+
+func (c *HttpClient) CreateAlert(body []byte) ([]byte, error) {
+	return c.send(http.MethodPost, c.ApiUrl+"/shodan/alert", body)
+}
+
+func (c *HttpClient) UpdateAlert(id string, body []byte) ([]byte, error) {
+	return c.send(http.MethodPost, c.ApiUrl+"/shodan/alert/"+url.PathEscape(id), body)
+}
+
+func (c *HttpClient) DeleteAlert(id string) error {
+	_, err := c.send(http.MethodDelete, c.ApiUrl+"/shodan/alert/"+url.PathEscape(id), nil)
+	return err
+}
+
+func (c *HttpClient) EnableTrigger(id string, trigger string) error {
+	_, err := c.send(http.MethodPut, c.ApiUrl+"/shodan/alert/"+url.PathEscape(id)+"/trigger/"+url.PathEscape(trigger), nil)
+	return err
+}
+
+func (c *HttpClient) DisableTrigger(id string, trigger string) error {
+	_, err := c.send(http.MethodDelete, c.ApiUrl+"/shodan/alert/"+url.PathEscape(id)+"/trigger/"+url.PathEscape(trigger), nil)
+	return err
+}
+
+func (c *HttpClient) get(endpoint string, params url.Values) ([]byte, error) {
+	params.Set("key", c.Key)
+	return c.send(http.MethodGet, endpoint+"?"+params.Encode(), nil)
+}
+
+// every request goes through the shared limiter, which also handles retries
+func (c *HttpClient) send(method string, endpoint string, body []byte) ([]byte, error) {
+	if !strings.Contains(endpoint, "?") {
+		endpoint = endpoint + "?" + url.Values{"key": {c.Key}}.Encode()
+	}
+
+	response, err := SharedLimiter().Do(func() (*http.Response, error) {
+		request, err := http.NewRequest(method, endpoint, bytes.NewReader(body))
+		if err != nil {
+			return nil, err
+		}
+		if body != nil {
+			request.Header.Set("Content-Type", "application/json")
+		}
+		return c.http.Do(request)
+	})
+	if err != nil {
+		return nil, err
+	}
+	defer response.Body.Close()
+
+	if response.StatusCode != http.StatusOK {
+		return nil, &StatusError{Code: response.StatusCode, Status: response.Status}
+	}
+
+	return io.ReadAll(response.Body)
+}
+
+type StatusError struct {
+	Code   int
+	Status string
+}
+
+func (e *StatusError) Error() string {
+	return fmt.Sprintf("http response error: %s", e.Status)
+}
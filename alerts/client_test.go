@@ -0,0 +1,229 @@
+package alerts
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+const testRss = `<?xml version="1.0" encoding="UTF-8"?>
+<rss version="2.0"><channel>
+<item>
+	<title>198.51.100.10 on port 3389 matched ` + "`open_database`" + `</title>
+	<link>https://monitor.shodan.io/events/1</link>
+	<description>Open database</description>
+	<pubDate>Mon, 12 Oct 2026 10:00:00 +0000</pubDate>
+</item>
+<item>
+	<title>198.51.100.11 on port 22 matched ` + "`end_of_life`" + `</title>
+	<link>https://monitor.shodan.io/events/2</link>
+	<description>End of life</description>
+	<pubDate>Mon, 12 Oct 2026 11:00:00 +0000</pubDate>
+</item>
+<item>
+	<title>a title with no address in it</title>
+	<pubDate>Mon, 12 Oct 2026 12:00:00 +0000</pubDate>
+</item>
+</channel></rss>`
+
+const testAlerts = `[
+	{"id": "WIDE", "name": "Acme Wide", "filters": {"ip": ["198.51.0.0/16"]}, "triggers": {}},
+	{"id": "ACME", "name": "Acme", "filters": {"ip": ["198.51.100.0/24"]}, "triggers": {"open_database": {}}}
+]`
+
+func TestDownloadRss(t *testing.T) {
+	client := newShodanServer(t, map[string]http.HandlerFunc{
+		"/events.rss": func(w http.ResponseWriter, r *http.Request) {
+			fmt.Fprint(w, testRss)
+		},
+		"/shodan/alert/info": jsonBody(testAlerts),
+	})
+	NewEventCache().ClearTable()
+
+	events, quarantined := DownloadRss(client)
+	if len(events) != 2 {
+		t.Fatalf("got %d events, want 2", len(events))
+	}
+	if len(quarantined) != 1 {
+		t.Fatalf("got %d quarantined items, want 1", len(quarantined))
+	}
+
+	tests := []struct {
+		ip      string
+		port    int
+		trigger string
+		alertId string
+		name    string
+	}{
+		{"198.51.100.10", 3389, "open_database", "ACME", "Acme"},
+		{"198.51.100.11", 22, "end_of_life", "ACME", "Acme"},
+	}
+	for i, test := range tests {
+		e := events[i]
+		if e.Ip != test.ip || e.TriggerPort != test.port || e.Trigger != test.trigger {
+			t.Errorf("event %d is %s:%d %q, want %s:%d %q", i, e.Ip, e.TriggerPort, e.Trigger, test.ip, test.port, test.trigger)
+		}
+		if e.AlertId() != test.alertId || e.Name() != test.name {
+			t.Errorf("event %d matched alert %q %q, want %q %q", i, e.AlertId(), e.Name(), test.alertId, test.name)
+		}
+	}
+
+	// the events are in the cache now, so a second download shows nothing new
+	events, _ = DownloadRss(client)
+	if len(events) != 0 {
+		t.Errorf("got %d events on the second download, want 0", len(events))
+	}
+}
+
+func TestDownloadRssFailure(t *testing.T) {
+	client := newShodanServer(t, map[string]http.HandlerFunc{
+		"/events.rss": func(w http.ResponseWriter, r *http.Request) {
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+		},
+	})
+
+	events, quarantined := DownloadRss(client)
+	if len(events) != 0 || len(quarantined) != 0 {
+		t.Errorf("got %d events and %d quarantined items from a failed download, want none", len(events), len(quarantined))
+	}
+}
+
+// a search of total hosts numbered from 10.1.0.0, served a page at a time, and a host
+// lookup answering every ip with one port
+func pagedSearchServer(t *testing.T, total int, pages *[]int) *HttpClient {
+	return newShodanServer(t, map[string]http.HandlerFunc{
+		"/shodan/host/search": func(w http.ResponseWriter, r *http.Request) {
+			page, _ := strconv.Atoi(r.URL.Query().Get("page"))
+			*pages = append(*pages, page)
+
+			matches := []string{}
+			for i := (page - 1) * searchPageSize; i < min(page*searchPageSize, total); i++ {
+				matches = append(matches, fmt.Sprintf(`{"ip_str": "10.1.%d.%d", "port": 443}`, i/256, i%256))
+			}
+			fmt.Fprintf(w, `{"matches": [%s], "total": %d}`, strings.Join(matches, ","), total)
+		},
+		"/shodan/host/": jsonBody(`{"ports": [443], "data": [{"port": 443, "transport": "tcp", "product": "nginx"}]}`),
+	})
+}
+
+func TestDownloadIpListPaging(t *testing.T) {
+	pages := []int{}
+	client := pagedSearchServer(t, 250, &pages)
+
+	events, warning := DownloadIpList(client, "Acme", "net:10.1.0.0/16")
+	if warning != "" {
+		t.Errorf("got warning %q, want none", warning)
+	}
+	if len(events) != 250 {
+		t.Fatalf("got %d events, want 250", len(events))
+	}
+	if fmt.Sprint(pages) != "[1 2 3]" {
+		t.Errorf("downloaded pages %v, want [1 2 3]", pages)
+	}
+
+	for _, e := range events {
+		if e.Incomplete() || e.ServiceLabel(443) == "" {
+			t.Fatalf("event %s was not loaded: errors %v", e.Ip, e.Errors())
+		}
+	}
+}
+
+func TestDownloadIpListTruncated(t *testing.T) {
+	t.Setenv("SHODAN_MAX_PAGES", "2")
+	pages := []int{}
+	client := pagedSearchServer(t, 250, &pages)
+
+	events, warning := DownloadIpList(client, "", "net:10.1.0.0/16")
+	if len(events) != 200 {
+		t.Errorf("got %d events, want 200", len(events))
+	}
+	if want := "Only 200 of 250 Shodan search results were downloaded"; warning != want {
+		t.Errorf("got warning %q, want %q", warning, want)
+	}
+}
+
+func TestLoadBannerStatus(t *testing.T) {
+	client := newShodanServer(t, map[string]http.HandlerFunc{
+		"/shodan/host/192.0.2.1": jsonBody(`{"ports": [80], "data": [{"port": 80, "transport": "tcp", "product": "Apache httpd", "version": "2.4.62"}]}`),
+		"/shodan/host/192.0.2.2": func(w http.ResponseWriter, r *http.Request) {
+			http.Error(w, `{"error": "No information available for that IP."}`, http.StatusNotFound)
+		},
+		"/shodan/host/192.0.2.3": func(w http.ResponseWriter, r *http.Request) {
+			http.Error(w, "broken", http.StatusInternalServerError)
+		},
+	})
+
+	tests := []struct {
+		ip         string
+		ports      int
+		incomplete bool
+		status     int
+	}{
+		// found hosts list their ports
+		{"192.0.2.1", 1, false, 0},
+		// shodan has nothing indexed, which is an empty host and not a failure
+		{"192.0.2.2", 0, false, 0},
+		// any other status is a failed load
+		{"192.0.2.3", 0, true, http.StatusInternalServerError},
+	}
+
+	for _, test := range tests {
+		e := NewEventFromIp(test.ip)
+		e.Load(client)
+
+		if len(e.Ports()) != test.ports {
+			t.Errorf("%s has %d ports, want %d", test.ip, len(e.Ports()), test.ports)
+		}
+		if e.Incomplete() != test.incomplete {
+			t.Errorf("%s incomplete is %v, want %v", test.ip, e.Incomplete(), test.incomplete)
+		}
+		if !test.incomplete {
+			continue
+		}
+
+		var status *StatusError
+		if len(e.Errors()) != 1 || e.Errors()[0].Stage != StageBanner || !errors.As(e.Errors()[0].Err, &status) || status.Code != test.status {
+			t.Errorf("%s errors are %v, want a %d banner error", test.ip, e.Errors(), test.status)
+		}
+	}
+}
+
+func TestDownloadIpListSearchFailure(t *testing.T) {
+	tests := []struct {
+		failFrom int
+		events   int
+		warning  string
+	}{
+		{1, 0, "Shodan search failed: http response error: 500 Internal Server Error"},
+		{2, 100, "Only 100 of 250 Shodan search results were downloaded, search failed: http response error: 500 Internal Server Error"},
+	}
+
+	for _, test := range tests {
+		client := newShodanServer(t, map[string]http.HandlerFunc{
+			"/shodan/host/search": func(w http.ResponseWriter, r *http.Request) {
+				page, _ := strconv.Atoi(r.URL.Query().Get("page"))
+				if page >= test.failFrom {
+					http.Error(w, "broken", http.StatusInternalServerError)
+					return
+				}
+				matches := []string{}
+				for i := 0; i < searchPageSize; i++ {
+					matches = append(matches, fmt.Sprintf(`{"ip_str": "10.2.0.%d", "port": 443}`, i))
+				}
+				fmt.Fprintf(w, `{"matches": [%s], "total": 250}`, strings.Join(matches, ","))
+			},
+			"/shodan/host/": jsonBody(`{"ports": [443], "data": [{"port": 443, "transport": "tcp"}]}`),
+		})
+
+		events, warning := DownloadIpList(client, "", "net:10.2.0.0/16")
+		if len(events) != test.events {
+			t.Errorf("search failing from page %d gave %d events, want %d", test.failFrom, len(events), test.events)
+		}
+		if warning != test.warning {
+			t.Errorf("search failing from page %d warned %q, want %q", test.failFrom, warning, test.warning)
+		}
+	}
+}
@@ -0,0 +1,154 @@
+package alerts
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// Cpe is a CPE 2.3 name split into its components. Components are unescaped, a component
+// that was "*" (any) or left off is empty and one that was "-" (not applicable) is kept as "-"
+type Cpe struct {
+	Part      string
+	Vendor    string
+	Product   string
+	Version   string
+	Update    string
+	Edition   string
+	Language  string
+	SwEdition string
+	TargetSw  string
+	TargetHw  string
+	Other     string
+}
+
+const cpePrefix = "cpe:2.3:"
+
+// ParseCpe reads a CPE 2.3 formatted string like "cpe:2.3:a:openbsd:openssh:8.2\:p1".
+// Shodan leaves the trailing components off, so anything after the part is optional
+func ParseCpe(formatted string) (Cpe, error) {
+	if !strings.HasPrefix(formatted, cpePrefix) {
+		return Cpe{}, fmt.Errorf("%q is not a cpe 2.3 formatted string", formatted)
+	}
+
+	components, err := splitCpe(strings.TrimPrefix(formatted, cpePrefix))
+	if err != nil {
+		return Cpe{}, fmt.Errorf("%q: %w", formatted, err)
+	}
+	if len(components) > 11 {
+		return Cpe{}, fmt.Errorf("%q has %d components, at most 11 are allowed", formatted, len(components))
+	}
+
+	// pad so every field can be read by position
+	for len(components) < 11 {
+		components = append(components, "")
+	}
+
+	cpe := Cpe{
+		Part:      components[0],
+		Vendor:    components[1],
+		Product:   components[2],
+		Version:   components[3],
+		Update:    components[4],
+		Edition:   components[5],
+		Language:  components[6],
+		SwEdition: components[7],
+		TargetSw:  components[8],
+		TargetHw:  components[9],
+		Other:     components[10],
+	}
+
+	if !oneOf(cpe.Part, "a", "o", "h") {
+		return Cpe{}, fmt.Errorf("%q has unknown part %q", formatted, cpe.Part)
+	}
+
+	return cpe, nil
+}
+
+// splits on the colons that aren't escaped, removing the escapes and turning "*" into ""
+func splitCpe(s string) ([]string, error) {
+	components := []string{}
+	current := strings.Builder{}
+	raw := strings.Builder{}
+
+	end := func() {
+		if raw.String() == "*" {
+			components = append(components, "")
+		} else {
+			components = append(components, current.String())
+		}
+		current.Reset()
+		raw.Reset()
+	}
+
+	for i := 0; i < len(s); i++ {
+		switch s[i] {
+		case '\\':
+			if i+1 == len(s) {
+				return nil, errors.New("ends with an unfinished escape")
+			}
+			i++
+			current.WriteByte(s[i])
+			raw.WriteByte('\\')
+			raw.WriteByte(s[i])
+		case ':':
+			end()
+		default:
+			current.WriteByte(s[i])
+			raw.WriteByte(s[i])
+		}
+	}
+	end()
+
+	return components, nil
+}
+
+// ParseCpes parses every cpe it can, the ones that can't be read are skipped
+func ParseCpes(formatted []string) []Cpe {
+	cpes := []Cpe{}
+	for _, f := range formatted {
+		cpe, err := ParseCpe(f)
+		if err != nil {
+			continue
+		}
+		cpes = append(cpes, cpe)
+	}
+
+	return cpes
+}
+
+// the cpe that most likely names the software a vulnerability is in, applications are
+// picked over operating systems and hardware, and versioned names over unversioned ones
+func primaryCpe(cpes []Cpe) Cpe {
+	best := Cpe{}
+	bestScore := -1
+
+	for _, cpe := range cpes {
+		score := 0
+		if cpe.Part == "a" {
+			score += 2
+		}
+		if cpe.Version != "" && cpe.Version != "-" {
+			score += 1
+		}
+		if score > bestScore {
+			best = cpe
+			bestScore = score
+		}
+	}
+
+	return best
+}
+
+// vendor, product and version joined with spaces, like "openbsd openssh 8.2:p1" for
+// "cpe:2.3:a:openbsd:openssh:8.2\:p1"
+func (c Cpe) Label() string {
+	parts := []string{}
+	for _, component := range []string{c.Vendor, c.Product, c.Version} {
+		if component != "" && component != "-" {
+			parts = append(parts, component)
+		}
+	}
+
+	return strings.Join(parts, " ")
+}
@@ -0,0 +1,69 @@
+package alerts
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestParseCpe(t *testing.T) {
+	tests := []struct {
+		formatted string
+		want      Cpe
+		label     string
+		err       string
+	}{
+		{`cpe:2.3:a:openbsd:openssh:8.2\:p1`, Cpe{Part: "a", Vendor: "openbsd", Product: "openssh", Version: "8.2:p1"}, "openbsd openssh 8.2:p1", ""},
+		// shodan leaves the trailing components off
+		{`cpe:2.3:a:apache:http_server`, Cpe{Part: "a", Vendor: "apache", Product: "http_server"}, "apache http_server", ""},
+		{`cpe:2.3:o:linux:linux_kernel:5.15:*:*:*:*:*:x64:*`, Cpe{Part: "o", Vendor: "linux", Product: "linux_kernel", Version: "5.15", TargetHw: "x64"}, "linux linux_kernel 5.15", ""},
+		// "*" is any and reads as empty, "-" is not applicable and is kept
+		{`cpe:2.3:h:cisco:asa_5505:-`, Cpe{Part: "h", Vendor: "cisco", Product: "asa_5505", Version: "-"}, "cisco asa_5505", ""},
+		{`cpe:2.3:a:vendor:product:*`, Cpe{Part: "a", Vendor: "vendor", Product: "product"}, "vendor product", ""},
+		// an escaped star is a literal star, and escaped backslashes and dots are unescaped
+		{`cpe:2.3:a:vendor:product:\*`, Cpe{Part: "a", Vendor: "vendor", Product: "product", Version: "*"}, "vendor product *", ""},
+		{`cpe:2.3:a:microsoft:internet_explorer:8\.0\.6001`, Cpe{Part: "a", Vendor: "microsoft", Product: "internet_explorer", Version: "8.0.6001"}, "microsoft internet_explorer 8.0.6001", ""},
+		{`cpe:2.3:a:vendor:back\\slash`, Cpe{Part: "a", Vendor: "vendor", Product: `back\slash`}, `vendor back\slash`, ""},
+		{`cpe:/a:openbsd:openssh:8.2`, Cpe{}, "", "not a cpe 2.3 formatted string"},
+		{`cpe:2.3:x:vendor:product`, Cpe{}, "", `unknown part "x"`},
+		{`cpe:2.3:a:vendor:product:1.0\`, Cpe{}, "", "unfinished escape"},
+		{`cpe:2.3:a:b:c:d:e:f:g:h:i:j:k:l`, Cpe{}, "", "has 12 components"},
+	}
+
+	for _, test := range tests {
+		cpe, err := ParseCpe(test.formatted)
+		if test.err != "" {
+			if err == nil || !strings.Contains(err.Error(), test.err) {
+				t.Errorf("ParseCpe(%q) gave error %v, want %q", test.formatted, err, test.err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("ParseCpe(%q): %v", test.formatted, err)
+			continue
+		}
+		if cpe != test.want {
+			t.Errorf("ParseCpe(%q) = %+v, want %+v", test.formatted, cpe, test.want)
+		}
+		if cpe.Label() != test.label {
+			t.Errorf("ParseCpe(%q).Label() = %q, want %q", test.formatted, cpe.Label(), test.label)
+		}
+	}
+}
+
+func TestPrimaryCpe(t *testing.T) {
+	cpes := ParseCpes([]string{
+		"cpe:2.3:o:canonical:ubuntu_linux",
+		"not a cpe",
+		"cpe:2.3:a:openbsd:openssh",
+		"cpe:2.3:a:openbsd:openssh:8.2",
+		"cpe:2.3:h:vendor:appliance:1.0",
+	})
+	if len(cpes) != 4 {
+		t.Fatalf("parsed %d cpes, want 4", len(cpes))
+	}
+
+	// the versioned application wins over the os, the hardware and the unversioned name
+	if label := primaryCpe(cpes).Label(); label != "openbsd openssh 8.2" {
+		t.Errorf("primary cpe is %q, want openbsd openssh 8.2", label)
+	}
+}
@@ -1,77 +1,174 @@
 package alerts
 
 import (
+	"fmt"
+	"sort"
+	"strconv"
 	"strings"
 )
 
 type Cve struct {
 	Name string
 	Summary string
+	// Rank is the index of the priority level in the active policy, Priority is its label
 	Rank int
+	Priority string
 	Epss float32
+	// Cvss, Version and Vector are taken from the newest cvss version in Scores
 	Cvss float32
 	Kev bool
 	Version string
+	Vector string
 	Severity string
+	// Vendor, Product and ProductVersion come from the cpe of the affected software
 	Vendor string
 	Product string
+	ProductVersion string
+	Scores []CvssScore
+
+	// filled in from the imported CISA KEV catalog
+	KevDateAdded string
+	KevDueDate string
+	KevAction string
+	KevRansomware bool
+
+	// EpssDate is the day the epss score is from, EpssModel is only set for imported scores
+	EpssPercentile float32
+	EpssDate string
+	EpssModel string
+}
+
+// CvssScore is a single cvss score, Version is the cvss version like "3.1" and
+// is empty when the source didn't say which version it scored with
+type CvssScore struct {
+	Version string
+	Score float32
+	Vector string
+}
+
+func (c CvssScore) Label() string {
+	if c.Version == "" {
+		return "CVSS"
+	}
+	return "CVSS " + c.Version
+}
+
+// orders the versions so the newest sorts first. Shodan's plain cvss field holds
+// its newest score, so an unversioned score is placed above 2.0
+func (c CvssScore) order() float64 {
+	if c.Version == "" {
+		return 2.5
+	}
+	version, _ := strconv.ParseFloat(c.Version, 64)
+	return version
 }
 
 func NewCve(name string, vuln Vuln, cpe []string) Cve {
 	newCve := Cve{}
-	var cvss float32 = 0.0
-	
-	if vuln.Cvss > vuln.CvssV2 {
-		cvss = vuln.Cvss
-		newCve.Version = "CVSS 1.0"
-	} else {
-		cvss = vuln.CvssV2
-		newCve.Version = "CVSS 2.0"
+
+	for _, score := range vuln.scores() {
+		newCve.AddScore(score)
 	}
-	rank, severity := rankCve(cvss, vuln.Epss, vuln.Kev)
-	vendor, product := getVendorProduct(cpe)
+	software := primaryCpe(ParseCpes(cpe))
 
 	newCve.Name = strings.TrimLeft(name, " ")
 	newCve.Summary = vuln.Summary
-	newCve.Rank = rank
 	newCve.Epss = vuln.Epss
-	newCve.Cvss = cvss
 	newCve.Kev = vuln.Kev
-	newCve.Severity = severity
-	newCve.Vendor = vendor
-	newCve.Product = product
+	newCve.Vendor = software.Vendor
+	newCve.Product = software.Product
+	newCve.ProductVersion = software.Version
+	newCve.rank()
 
 	return newCve
 }
 
-func rankCve(cvss float32, epss float32, kev bool) (int, string) {
-	var cvssScore float32 = 6.0
-	var epssScore float32 = 0.2
-
-	if kev {
-		return 0, "HIGH"
-	} else if cvss >= cvssScore {
-		if epss >= epssScore {
-			return 1, "HIGH"
-		} else {
-			return 2, "MODERATE"
-		}
-	} else {
-		if epss >= epssScore {
-			return 3, "MODERATE"
-		} else {
-			return 4, "LOW"
+// AddScore keeps one score per cvss version and moves Cvss, Version and Vector to the newest one
+func (c *Cve) AddScore(score CvssScore) {
+	if score.Version == "" {
+		score.Version = vectorVersion(score.Vector)
+	}
+
+	replaced := false
+	for i, existing := range c.Scores {
+		if existing.Version == score.Version {
+			c.Scores[i] = score
+			replaced = true
 		}
 	}
+	if !replaced {
+		c.Scores = append(c.Scores, score)
+	}
+
+	sort.SliceStable(c.Scores, func(i, j int) bool {
+		return c.Scores[i].order() > c.Scores[j].order()
+	})
+
+	newest := c.Newest()
+	c.Cvss = newest.Score
+	c.Version = newest.Label()
+	c.Vector = newest.Vector
 }
 
-func getVendorProduct(cpes []string) (string, string) {
-	for _, cpe := range cpes {
-		cpe_split := strings.Split(cpe, ":")
-		if len(cpe_split) > 4 {
-			return cpe_split[3], cpe_split[4]
+// the score from the newest cvss version available
+func (c *Cve) Newest() CvssScore {
+	if len(c.Scores) == 0 {
+		return CvssScore{}
+	}
+	return c.Scores[0]
+}
+
+// vectors start with the version they were written in, "CVSS:3.1/AV:N/..."
+func vectorVersion(vector string) string {
+	if !strings.HasPrefix(vector, "CVSS:") {
+		return ""
+	}
+	version, _, _ := strings.Cut(strings.TrimPrefix(vector, "CVSS:"), "/")
+	return version
+}
+
+// the major part of a cvss version, "3" for "3.1"
+func majorVersion(version string) string {
+	major, _, _ := strings.Cut(version, ".")
+	return major
+}
+
+// every score shodan sent for the vulnerability
+func (v Vuln) scores() []CvssScore {
+	scores := []CvssScore{}
+
+	if v.CvssV2 > 0 || v.CvssV2Vector != "" {
+		scores = append(scores, CvssScore{Version: "2.0", Score: v.CvssV2, Vector: v.CvssV2Vector})
+	}
+	if v.CvssV3 > 0 || v.CvssV3Vector != "" {
+		version := vectorVersion(v.CvssV3Vector)
+		if version == "" {
+			version = "3.1"
+		}
+		scores = append(scores, CvssScore{Version: version, Score: v.CvssV3, Vector: v.CvssV3Vector})
+	}
+	if v.CvssV4 > 0 || v.CvssV4Vector != "" {
+		scores = append(scores, CvssScore{Version: "4.0", Score: v.CvssV4, Vector: v.CvssV4Vector})
+	}
+
+	// the plain cvss field is a copy of one of the above, which can name a different
+	// minor version like 3.0 for a 3.1 score, so any score of the same major version covers it
+	if v.Cvss > 0 {
+		version := vectorVersion(v.CvssVector)
+		if v.CvssVersion > 0 {
+			version = fmt.Sprintf("%.1f", v.CvssVersion)
+		}
+
+		duplicate := false
+		for _, score := range scores {
+			if (version != "" && majorVersion(score.Version) == majorVersion(version)) || (version == "" && score.Score == v.Cvss) {
+				duplicate = true
+			}
+		}
+		if !duplicate {
+			scores = append(scores, CvssScore{Version: version, Score: v.Cvss, Vector: v.CvssVector})
 		}
 	}
 
-	return "", ""
+	return scores
 }
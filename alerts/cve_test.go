@@ -0,0 +1,41 @@
+package alerts
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestVulnScores(t *testing.T) {
+	const v31 = "CVSS:3.1/AV:N/AC:L/PR:N/UI:N/S:U/C:H/I:H/A:H"
+	const v30 = "CVSS:3.0/AV:N/AC:L/PR:N/UI:N/S:U/C:H/I:H/A:H"
+
+	tests := []struct {
+		name string
+		vuln Vuln
+		want string
+	}{
+		{"v3 only", Vuln{CvssV3: 9.8, CvssV3Vector: v31}, "[3.1 9.8]"},
+		{"v3 without a vector", Vuln{CvssV3: 9.8}, "[3.1 9.8]"},
+		{"v2 and v3", Vuln{CvssV2: 7.5, CvssV3: 9.8, CvssV3Vector: v30}, "[2.0 7.5 3.0 9.8]"},
+		// the plain field copies the v3 score under the older minor version
+		{"plain 3.0 beside 3.1", Vuln{CvssV3: 9.8, CvssV3Vector: v31, Cvss: 9.8, CvssVersion: 3}, "[3.1 9.8]"},
+		{"plain 3.0 vector beside 3.1", Vuln{CvssV3: 9.8, CvssV3Vector: v31, Cvss: 9.8, CvssVector: v30}, "[3.1 9.8]"},
+		{"plain 2.0 beside 2.0", Vuln{CvssV2: 7.5, Cvss: 7.5, CvssVersion: 2}, "[2.0 7.5]"},
+		{"plain unversioned copy", Vuln{CvssV2: 7.5, Cvss: 7.5}, "[2.0 7.5]"},
+		// a plain score of a version nothing else covers is kept
+		{"plain 3.1 beside 2.0", Vuln{CvssV2: 7.5, Cvss: 9.8, CvssVersion: 3.1}, "[2.0 7.5 3.1 9.8]"},
+		{"plain unversioned score", Vuln{CvssV2: 7.5, Cvss: 9.8}, "[2.0 7.5  9.8]"},
+		{"plain only", Vuln{Cvss: 5.3}, "[ 5.3]"},
+		{"nothing", Vuln{}, "[]"},
+	}
+
+	for _, test := range tests {
+		got := []string{}
+		for _, score := range test.vuln.scores() {
+			got = append(got, score.Version, fmt.Sprint(score.Score))
+		}
+		if fmt.Sprint(got) != test.want {
+			t.Errorf("%s: got scores %v, want %s", test.name, got, test.want)
+		}
+	}
+}
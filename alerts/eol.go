@@ -0,0 +1,251 @@
+package alerts
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+const eolPath = "./resources/eol.json"
+
+// EolProduct is a product of the end of life dataset, laid out like an endoflife.date product.
+// A service is this product when one of its cpes is "vendor:product" from Cpes, its banner
+// has no cpes and its product is one of Products, or its os contains one of Os
+type EolProduct struct {
+	Name     string     `json:"name"`
+	Products []string   `json:"products"`
+	Cpes     []string   `json:"cpes"`
+	Os       []string   `json:"os"`
+	Cycles   []EolCycle `json:"cycles"`
+}
+
+// EolCycle is a release cycle, a version is in the cycle when it starts with it. An empty
+// cycle holds every version
+type EolCycle struct {
+	Cycle string  `json:"cycle"`
+	Eol   EolDate `json:"eol"`
+}
+
+// EolDate is either the end of support date or, like on endoflife.date, just true or false
+type EolDate struct {
+	Date  time.Time
+	Ended bool
+}
+
+func (d *EolDate) UnmarshalJSON(data []byte) error {
+	var ended bool
+	if err := json.Unmarshal(data, &ended); err == nil {
+		d.Ended = ended
+		return nil
+	}
+
+	var date string
+	if err := json.Unmarshal(data, &date); err != nil {
+		return err
+	}
+
+	parsed, err := time.Parse(time.DateOnly, date)
+	if err != nil {
+		return fmt.Errorf("invalid eol date %q", date)
+	}
+	d.Date = parsed
+	return nil
+}
+
+// true once support has ended
+func (d EolDate) Passed(now time.Time) bool {
+	if d.Date.IsZero() {
+		return d.Ended
+	}
+	return !d.Date.After(now)
+}
+
+// EolMatch is a service running software that is past its end of support
+type EolMatch struct {
+	Product string
+	Version string
+	Cycle   string
+	Date    time.Time
+}
+
+// the end of support date, or "unknown" when the dataset only says support has ended
+func (m EolMatch) DateString() string {
+	if m.Date.IsZero() {
+		return "unknown"
+	}
+	return m.Date.Format(time.DateOnly)
+}
+
+var (
+	eolProducts     []EolProduct
+	eolProductsOnce sync.Once
+)
+
+// EolProducts is the dataset in resources/eol.json, read on first use
+func EolProducts() []EolProduct {
+	eolProductsOnce.Do(func() {
+		body, err := os.ReadFile(eolPath)
+		if err != nil {
+			fmt.Println("Error: reading end of life dataset", err.Error())
+			return
+		}
+
+		if err := json.Unmarshal(body, &eolProducts); err != nil {
+			fmt.Println("Error: parsing end of life dataset", err.Error())
+		}
+	})
+	return eolProducts
+}
+
+// the ways a service is told to be a product, each is tried over every product before the
+// next so a cpe naming one product wins over a banner product naming another
+var eolIdentifiers = []func(EolProduct, Service) (string, bool){
+	EolProduct.identifyCpe,
+	EolProduct.identifyProduct,
+	EolProduct.identifyOs,
+}
+
+// finds the end of life software the service runs, the cpe version is used over the banner version
+func findEol(service Service, now time.Time) *EolMatch {
+	for _, identify := range eolIdentifiers {
+		for _, product := range EolProducts() {
+			version, ok := identify(product, service)
+			if !ok {
+				continue
+			}
+
+			cycle, ok := product.cycle(version)
+			if !ok || !cycle.Eol.Passed(now) {
+				continue
+			}
+
+			return &EolMatch{
+				Product: product.Name,
+				Version: version,
+				Cycle:   cycle.Cycle,
+				Date:    cycle.Eol.Date,
+			}
+		}
+	}
+
+	return nil
+}
+
+// a cpe with no version only borrows the banner version when the banner names the same
+// product, the version of another product on the port would say nothing about this one
+func (p EolProduct) identifyCpe(service Service) (string, bool) {
+	for _, cpe := range service.Cpes {
+		for _, name := range p.Cpes {
+			if !strings.EqualFold(cpe.Vendor+":"+cpe.Product, name) {
+				continue
+			}
+			if cpe.Version != "" && cpe.Version != "-" {
+				return cpe.Version, true
+			}
+			if p.namesProduct(service.Product) {
+				return service.Version, true
+			}
+		}
+	}
+	return "", false
+}
+
+// the banner product has to be the whole name, so "PHP" doesn't match "phpMyAdmin". Banners
+// with application cpes already say what they run, so their product isn't used
+func (p EolProduct) identifyProduct(service Service) (string, bool) {
+	for _, cpe := range service.Cpes {
+		if cpe.Part == "a" {
+			return "", false
+		}
+	}
+
+	if p.namesProduct(service.Product) {
+		return service.Version, true
+	}
+	return "", false
+}
+
+// true when the banner product is one of the product's names
+func (p EolProduct) namesProduct(product string) bool {
+	for _, name := range p.Products {
+		if product != "" && strings.EqualFold(strings.TrimSpace(product), name) {
+			return true
+		}
+	}
+	return false
+}
+
+func (p EolProduct) identifyOs(service Service) (string, bool) {
+	for _, name := range p.Os {
+		if service.Os != "" && strings.Contains(strings.ToLower(service.Os), strings.ToLower(name)) {
+			return "", true
+		}
+	}
+	return "", false
+}
+
+// the longest cycle the version is in
+func (p EolProduct) cycle(version string) (EolCycle, bool) {
+	best := EolCycle{}
+	found := false
+
+	for _, cycle := range p.Cycles {
+		if !inCycle(version, cycle.Cycle) {
+			continue
+		}
+		if !found || len(cycle.Cycle) > len(best.Cycle) {
+			best = cycle
+			found = true
+		}
+	}
+
+	return best, found
+}
+
+// "7.4.3" and "7.4p1" are in cycle "7.4", "7.40" is not
+func inCycle(version string, cycle string) bool {
+	if cycle == "" {
+		return true
+	}
+	if !strings.HasPrefix(version, cycle) {
+		return false
+	}
+
+	rest := version[len(cycle):]
+	return rest == "" || rest[0] < '0' || rest[0] > '9'
+}
+
+// the end of life software on a port, nil when there is none
+func (e *Event) Eol(port int) *EolMatch {
+	service, ok := e.Services()[port]
+	if !ok {
+		return nil
+	}
+	return service.Eol
+}
+
+// EolService is an end of life finding on a host, for listing in reports
+type EolService struct {
+	Ip   string
+	Port int
+	EolMatch
+}
+
+// EolServices lists every end of life service of the events, ordered by ip and then port
+func EolServices(events []*Event) []EolService {
+	services := []EolService{}
+
+	for _, e := range events {
+		ports := e.Services()
+		for _, port := range sortedPorts(ports) {
+			if eol := ports[port].Eol; eol != nil {
+				services = append(services, EolService{Ip: e.Ip, Port: port, EolMatch: *eol})
+			}
+		}
+	}
+
+	return services
+}
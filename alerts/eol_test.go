@@ -0,0 +1,55 @@
+package alerts
+
+import (
+	"testing"
+	"time"
+)
+
+func TestFindEol(t *testing.T) {
+	now := time.Date(2030, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name    string
+		service Service
+		product string
+		version string
+	}{
+		{"php", Service{Product: "PHP", Version: "7.4.3"}, "PHP", "7.4.3"},
+		{"php in lower case", Service{Product: "php", Version: "7.4.3"}, "PHP", "7.4.3"},
+		// phpMyAdmin only starts with php, its 4.9 is not php 4
+		{"phpmyadmin", Service{Product: "phpMyAdmin", Version: "4.9.7"}, "", ""},
+		{"phpmyadmin by cpe", Service{Product: "phpMyAdmin", Version: "4.9.7", Cpes: ParseCpes([]string{"cpe:2.3:a:phpmyadmin:phpmyadmin:4.9.7"})}, "", ""},
+		{"tomcat", Service{Product: "Apache Tomcat", Version: "8.5.100"}, "Apache Tomcat", "8.5.100"},
+		{"tomcat connector", Service{Product: "Apache Tomcat/Coyote JSP engine", Version: "7.0.109"}, "Apache Tomcat", "7.0.109"},
+		{"httpd", Service{Product: "Apache httpd", Version: "2.2.34"}, "Apache HTTP Server", "2.2.34"},
+		{"supported httpd", Service{Product: "Apache httpd", Version: "2.4.62"}, "", ""},
+		// tomcat fronted by httpd names httpd in the banner, the cpe says what answered
+		{"tomcat behind httpd", Service{Product: "Apache httpd", Version: "2.4.62", Cpes: ParseCpes([]string{"cpe:2.3:a:apache:tomcat:8.0.53"})}, "Apache Tomcat", "8.0.53"},
+		{"httpd cpe over tomcat product", Service{Product: "Apache Tomcat", Version: "9.0.1", Cpes: ParseCpes([]string{"cpe:2.3:a:apache:http_server:2.2.15"})}, "Apache HTTP Server", "2.2.15"},
+		// an operating system cpe says nothing about the application, so the product is used
+		{"iis with an os cpe", Service{Product: "Microsoft IIS httpd", Version: "7.5", Cpes: ParseCpes([]string{"cpe:2.3:o:microsoft:windows"})}, "Microsoft IIS", "7.5"},
+		{"cpe without a version", Service{Product: "OpenSSL", Version: "1.0.2k", Cpes: ParseCpes([]string{"cpe:2.3:a:openssl:openssl"})}, "OpenSSL", "1.0.2k"},
+		// the openssh version is not the version of the openssl it was built with
+		{"cpe without a version for another product", Service{Product: "OpenSSH", Version: "1.0.2k", Cpes: ParseCpes([]string{"cpe:2.3:a:openssl:openssl"})}, "", ""},
+		{"cpe with a version for another product", Service{Product: "OpenSSH", Version: "7.4", Cpes: ParseCpes([]string{"cpe:2.3:a:openssl:openssl:1.0.2k"})}, "OpenSSL", "1.0.2k"},
+		{"os", Service{Product: "Microsoft IIS httpd", Version: "10.0", Os: "Windows Server 2008 R2 Standard"}, "Windows Server 2008 R2", ""},
+		{"nothing known", Service{Product: "nginx", Version: "1.26.0"}, "", ""},
+	}
+
+	for _, test := range tests {
+		match := findEol(test.service, now)
+		if test.product == "" {
+			if match != nil {
+				t.Errorf("%s: matched %s %s, want no match", test.name, match.Product, match.Version)
+			}
+			continue
+		}
+		if match == nil {
+			t.Errorf("%s: no match, want %s %s", test.name, test.product, test.version)
+			continue
+		}
+		if match.Product != test.product || match.Version != test.version {
+			t.Errorf("%s: matched %s %s, want %s %s", test.name, match.Product, match.Version, test.product, test.version)
+		}
+	}
+}
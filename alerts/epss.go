@@ -0,0 +1,210 @@
+package alerts
+
+import (
+	"bufio"
+	"compress/gzip"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// the first line of the FIRST csv looks like "#model_version:v2023.03.01,score_date:2024-10-18T00:00:00+0000",
+// some copies of it write the zone as "Z" instead
+var epssDateLayouts = []string{"2006-01-02T15:04:05-0700", time.RFC3339}
+
+// EpssScore is one row of the FIRST daily EPSS csv
+type EpssScore struct {
+	Score      float32
+	Percentile float32
+}
+
+// EpssModel says which model run the imported scores came from
+type EpssModel struct {
+	Version string
+	Date    time.Time
+}
+
+func (m EpssModel) String() string {
+	if m.Version == "" {
+		return m.Date.Format(time.DateOnly)
+	}
+	return m.Version + " (" + m.Date.Format(time.DateOnly) + ")"
+}
+
+func (v *VulnStore) ensureEpssTables() {
+	v.db.Exec(`CREATE TABLE IF NOT EXISTS epss(
+cve TEXT PRIMARY KEY,
+score REAL,
+percentile REAL
+)`)
+	v.db.Exec(`CREATE TABLE IF NOT EXISTS epss_model(
+version TEXT,
+date INTEGER
+)`)
+}
+
+// ImportEpss replaces the stored scores with the FIRST EPSS csv at path, gzipped or not
+func (v *VulnStore) ImportEpss(path string) (int, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return 0, err
+	}
+	defer file.Close()
+
+	reader := bufio.NewReader(file)
+	if magic, _ := reader.Peek(2); len(magic) == 2 && magic[0] == 0x1f && magic[1] == 0x8b {
+		unzipped, err := gzip.NewReader(reader)
+		if err != nil {
+			return 0, err
+		}
+		defer unzipped.Close()
+		reader = bufio.NewReader(unzipped)
+	}
+
+	model, err := readEpssModel(reader)
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", path, err)
+	}
+
+	rows := csv.NewReader(reader)
+	header, err := rows.Read()
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", path, err)
+	}
+	if len(header) < 3 || header[0] != "cve" || header[1] != "epss" || header[2] != "percentile" {
+		return 0, fmt.Errorf("%s: unexpected header %q", path, strings.Join(header, ","))
+	}
+
+	tx, err := v.db.Begin()
+	if err != nil {
+		return 0, err
+	}
+
+	if _, err := tx.Exec(`DELETE FROM epss`); err != nil {
+		tx.Rollback()
+		return 0, err
+	}
+
+	count := 0
+	for {
+		row, err := rows.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			tx.Rollback()
+			return 0, fmt.Errorf("%s: %w", path, err)
+		}
+
+		score, scoreErr := strconv.ParseFloat(row[1], 64)
+		percentile, percentileErr := strconv.ParseFloat(row[2], 64)
+		if scoreErr != nil || percentileErr != nil {
+			tx.Rollback()
+			return 0, fmt.Errorf("%s: invalid scores for %s", path, row[0])
+		}
+
+		_, err = tx.Exec(`INSERT OR REPLACE INTO epss(cve, score, percentile) VALUES (?,?,?)`, row[0], score, percentile)
+		if err != nil {
+			tx.Rollback()
+			return 0, err
+		}
+		count++
+	}
+
+	if count == 0 {
+		tx.Rollback()
+		return 0, fmt.Errorf("%s has no scores", path)
+	}
+
+	if _, err := tx.Exec(`DELETE FROM epss_model`); err != nil {
+		tx.Rollback()
+		return 0, err
+	}
+	if _, err := tx.Exec(`INSERT INTO epss_model(version, date) VALUES (?,?)`, model.Version, model.Date.Unix()); err != nil {
+		tx.Rollback()
+		return 0, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, err
+	}
+
+	reloadCatalogs()
+	return count, nil
+}
+
+func readEpssModel(reader *bufio.Reader) (EpssModel, error) {
+	model := EpssModel{}
+
+	line, err := reader.ReadString('\n')
+	if err != nil {
+		return model, err
+	}
+	if !strings.HasPrefix(line, "#") {
+		return model, errors.New("missing the #model_version,score_date line")
+	}
+
+	for _, field := range strings.Split(strings.TrimSpace(strings.TrimPrefix(line, "#")), ",") {
+		key, value, _ := strings.Cut(field, ":")
+		switch key {
+		case "model_version":
+			model.Version = value
+		case "score_date":
+			date, err := parseEpssDate(value)
+			if err != nil {
+				return model, err
+			}
+			model.Date = date
+		}
+	}
+
+	if model.Date.IsZero() {
+		return model, errors.New("missing score_date")
+	}
+	return model, nil
+}
+
+func parseEpssDate(value string) (time.Time, error) {
+	for _, layout := range epssDateLayouts {
+		if date, err := time.Parse(layout, value); err == nil {
+			return date, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("invalid score_date %q", value)
+}
+
+func (v *VulnStore) epssScores() (map[string]EpssScore, EpssModel) {
+	scores := make(map[string]EpssScore)
+	model := EpssModel{}
+
+	var date int64
+	err := v.db.QueryRow(`SELECT version, date FROM epss_model`).Scan(&model.Version, &date)
+	if err != nil {
+		return scores, model
+	}
+	model.Date = time.Unix(date, 0).UTC()
+
+	rows, err := v.db.Query(`SELECT cve, score, percentile FROM epss`)
+	if err != nil {
+		fmt.Println("querying", err.Error())
+		return scores, model
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var cve string
+		score := EpssScore{}
+		if err := rows.Scan(&cve, &score.Score, &score.Percentile); err != nil {
+			fmt.Println("scanning", err.Error())
+			continue
+		}
+		scores[cve] = score
+	}
+
+	return scores, model
+}
@@ -0,0 +1,116 @@
+package alerts
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+const testEpss = `#model_version:v2025.03.14,score_date:2026-10-01T00:00:00+0000
+cve,epss,percentile
+CVE-2099-0001,0.97000,0.99900
+CVE-2099-0002,0.00100,0.20000
+`
+
+func writeTestFile(t *testing.T, name string, body []byte) string {
+	t.Helper()
+
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, body, 0644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestImportEpss(t *testing.T) {
+	store := NewVulnStore()
+	model := EpssModel{Version: "v2025.03.14", Date: time.Date(2026, 10, 1, 0, 0, 0, 0, time.UTC)}
+
+	tests := []struct {
+		name string
+		body []byte
+	}{
+		{"epss.csv", []byte(testEpss)},
+		{"epss.csv.gz", gzipped(t, testEpss)},
+		// the same date with its zone written as Z
+		{"epss-z.csv", []byte(strings.Replace(testEpss, "+0000", "Z", 1))},
+	}
+
+	for _, test := range tests {
+		count, err := store.ImportEpss(writeTestFile(t, test.name, test.body))
+		if err != nil || count != 2 {
+			t.Errorf("%s imported %d scores with error %v, want 2", test.name, count, err)
+			continue
+		}
+
+		scores, stored := store.epssScores()
+		if stored.Version != model.Version || !stored.Date.Equal(model.Date) {
+			t.Errorf("%s stored model %v, want %v", test.name, stored, model)
+		}
+		if score := scores["CVE-2099-0001"]; score.Score != 0.97 || score.Percentile != 0.999 {
+			t.Errorf("%s stored %v for CVE-2099-0001", test.name, score)
+		}
+	}
+}
+
+func TestImportEpssInvalid(t *testing.T) {
+	store := NewVulnStore()
+
+	tests := []struct {
+		name   string
+		body   string
+		reason string
+	}{
+		{"no model line", "cve,epss,percentile\nCVE-2099-0001,0.9,0.9\n", "missing the #model_version,score_date line"},
+		{"no score date", "#model_version:v2025.03.14\ncve,epss,percentile\nCVE-2099-0001,0.9,0.9\n", "missing score_date"},
+		{"bad score date", "#model_version:v2025.03.14,score_date:yesterday\ncve,epss,percentile\n", `invalid score_date "yesterday"`},
+		{"bad header", "#model_version:v2025.03.14,score_date:2026-10-01T00:00:00Z\ncve,score,percentile\nCVE-2099-0001,0.9,0.9\n", "unexpected header"},
+		{"bad score", "#model_version:v2025.03.14,score_date:2026-10-01T00:00:00Z\ncve,epss,percentile\nCVE-2099-0001,high,0.9\n", "invalid scores for CVE-2099-0001"},
+		{"no scores", "#model_version:v2025.03.14,score_date:2026-10-01T00:00:00Z\ncve,epss,percentile\n", "has no scores"},
+	}
+
+	for _, test := range tests {
+		_, err := store.ImportEpss(writeTestFile(t, "epss.csv", []byte(test.body)))
+		if err == nil || !strings.Contains(err.Error(), test.reason) {
+			t.Errorf("%s: got error %v, want %q", test.name, err, test.reason)
+		}
+	}
+}
+
+// the imported score is only taken over shodan's when its model ran after the scan
+func TestEnrichEpss(t *testing.T) {
+	if _, err := NewVulnStore().ImportEpss(writeTestFile(t, "epss.csv", []byte(testEpss))); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name       string
+		scanned    time.Time
+		epss       float32
+		date       string
+		model      string
+		percentile float32
+	}{
+		{"scanned before the model", time.Date(2026, 9, 1, 0, 0, 0, 0, time.UTC), 0.97, "2026-10-01", "v2025.03.14 (2026-10-01)", 0.999},
+		{"scanned after the model", time.Date(2026, 10, 5, 0, 0, 0, 0, time.UTC), 0.5, "2026-10-05", "", 0},
+		{"scan date unknown", time.Time{}, 0.97, "2026-10-01", "v2025.03.14 (2026-10-01)", 0.999},
+	}
+
+	for _, test := range tests {
+		cve := NewCve("CVE-2099-0001", Vuln{Cvss: 9.8, Epss: 0.5}, nil)
+		cve.enrich(test.scanned)
+		if cve.Epss != test.epss || cve.EpssDate != test.date || cve.EpssModel != test.model || cve.EpssPercentile != test.percentile {
+			t.Errorf("%s: epss %v from %q by %q at percentile %v, want %v from %q by %q at percentile %v", test.name,
+				cve.Epss, cve.EpssDate, cve.EpssModel, cve.EpssPercentile, test.epss, test.date, test.model, test.percentile)
+		}
+	}
+
+	// a cve the import has no score for keeps shodan's
+	cve := NewCve("CVE-2099-0003", Vuln{Cvss: 9.8, Epss: 0.5}, nil)
+	cve.enrich(time.Date(2026, 9, 1, 0, 0, 0, 0, time.UTC))
+	if cve.Epss != 0.5 || cve.EpssModel != "" {
+		t.Errorf("unscored cve has epss %v from %q, want shodan's 0.5", cve.Epss, cve.EpssModel)
+	}
+}
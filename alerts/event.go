@@ -1,12 +1,13 @@
 package alerts
 
 import (
+	"context"
 	"encoding/json"
-	"encoding/xml"
+	"errors"
 	"fmt"
-	"io"
 	"net/http"
 	"os"
+	"slices"
 	"sort"
 	"strconv"
 	"strings"
@@ -23,242 +24,284 @@ type Event struct {
 	Desc        string
 	Timestamp   time.Time
 
-	Loaded  bool
-	AlertId string
-	Name    string
-	Ports   map[int][]Cve
+	// the hostnames from the address fields that resolved to the ip
+	Hostnames []string
+
+	errorsMu   sync.Mutex
+	loadErrors []LoadError
+
+	// done is closed once the current load finishes. The loaded fields are only read
+	// through their getters, a retry builds them again and swaps them in under loadMu
+	loadMu   sync.Mutex
+	started  bool
+	done     chan struct{}
+	alertId  string
+	name     string
+	ports    map[int][]Cve
+	services map[int]Service
 }
 
-func NewEventFromItem(item Item) Event {
-	splitTitle := strings.Split(item.Title, " ")
-	ip := splitTitle[0]
-	trigger := strings.ReplaceAll(splitTitle[len(splitTitle)-1], "`", "")
-	port, _ := strconv.Atoi(splitTitle[3])
+const (
+	StageAlertId = "alert id"
+	StageBanner  = "host banner"
+)
 
-	timestamp, err := time.Parse("Sun, 20 Oct 2024 14:33:09 +0000", item.PubDate)
-	if err != nil {
-		timestamp = time.Now()
-	}
+// LoadError records which part of an event failed to download, so a missing
+// name or an empty port list can be told apart from real data
+type LoadError struct {
+	Stage string
+	Err   error
+}
 
-	return Event{
-		Ip:          ip,
-		Trigger:     trigger,
-		TriggerPort: port,
-		AlertLink:   item.Link,
-		HostLink:    "https://www.shodan.io/host/" + ip,
-		Desc:        item.Description + " on port " + strconv.Itoa(port),
-		Timestamp:   timestamp,
-		Ports:       make(map[int][]Cve),
-		Loaded:      false,
-	}
+func (l LoadError) Error() string {
+	return l.Stage + ": " + l.Err.Error()
+}
+
+func (e *Event) addError(stage string, err error) {
+	e.errorsMu.Lock()
+	defer e.errorsMu.Unlock()
+	e.loadErrors = append(e.loadErrors, LoadError{Stage: stage, Err: err})
+}
+
+// the parts of the last load that failed, copied so a retry can clear them meanwhile
+func (e *Event) Errors() []LoadError {
+	e.errorsMu.Lock()
+	defer e.errorsMu.Unlock()
+	return slices.Clone(e.loadErrors)
+}
+
+// true when any part of the event failed to load
+func (e *Event) Incomplete() bool {
+	e.errorsMu.Lock()
+	defer e.errorsMu.Unlock()
+	return len(e.loadErrors) > 0
 }
 
-func NewEventFromIp(ip string) Event {
-	return Event{
+func NewEventFromIp(ip string) *Event {
+	return &Event{
 		Ip:       ip,
 		HostLink: "https://www.shodan.io/host/" + ip,
-		Ports:    make(map[int][]Cve),
+		ports:    make(map[int][]Cve),
+		services: make(map[int]Service),
+		done:     make(chan struct{}),
 	}
 }
 
-func (e *Event) Load() {
-	if e.Loaded == true {
+// Load downloads the event once, calling it while another load is running waits for that one instead
+func (e *Event) Load(client ShodanClient) {
+	e.loadMu.Lock()
+	if e.started {
+		done := e.done
+		e.loadMu.Unlock()
+		<-done
 		return
 	}
+	e.started = true
+	done := e.done
+	e.loadMu.Unlock()
 
-	go func(e *Event) {
-		e.getAlertId(0)
-		e.getName(0)
-	}(e)
-
-	bannerChannel := make(chan Banner)
+	e.load(client)
+	close(done)
+}
 
-	go func(e *Event, ch chan Banner) {
-		banner := e.getBanner(0)
-		ch <- banner
-	}(e, bannerChannel)
+// clears the errors from the last attempt and loads the event again, calling it while
+// another load is running waits for that one instead
+func (e *Event) Retry(client ShodanClient) {
+	e.loadMu.Lock()
+	if !e.started {
+		e.loadMu.Unlock()
+		e.Load(client)
+		return
+	}
+	select {
+	case <-e.done:
+	default:
+		done := e.done
+		e.loadMu.Unlock()
+		<-done
+		return
+	}
+	e.done = make(chan struct{})
+	done := e.done
+	e.loadMu.Unlock()
 
-	banner := <-bannerChannel
-	e.parseCves(banner)
+	e.errorsMu.Lock()
+	e.loadErrors = nil
+	e.errorsMu.Unlock()
 
-	e.Loaded = true
+	e.load(client)
+	close(done)
 }
 
-func (e *Event) getAlertId(retries int) {
-	url := e.AlertLink + "?key=" + os.Getenv("API_KEY")
-	response, err := http.Get(url)
-	if err != nil {
-		e.AlertId = "Could not get AlertID" + err.Error()
-		return
+func (e *Event) load(client ShodanClient) {
+	// feed events are matched when the feed is downloaded, this only runs again on a retry
+	if e.AlertLink != "" && e.AlertId() == "" {
+		index, err := DownloadAlerts(client)
+		e.matchAlert(index, err)
 	}
 
-	if response.StatusCode == http.StatusTooManyRequests {
-		if retries == 5 {
-			e.AlertId = fmt.Sprintf("http response error: %s", response.Status)
-		} else {
-			time.Sleep(time.Second * time.Duration((retries + 1)))
-			e.getAlertId(retries + 1)
-		}
-		return
+	ports, services := e.loadBanner(client)
+
+	e.loadMu.Lock()
+	e.ports = ports
+	e.services = services
+	e.loadMu.Unlock()
+}
+
+// the id of the network alert covering the event, empty until it is matched
+func (e *Event) AlertId() string {
+	e.loadMu.Lock()
+	defer e.loadMu.Unlock()
+	return e.alertId
+}
+
+// the name of the network alert covering the event, which names the organization
+func (e *Event) Name() string {
+	e.loadMu.Lock()
+	defer e.loadMu.Unlock()
+	return e.name
+}
+
+// the cves on each port of the host. The map is never changed once it is swapped in,
+// so it can be read after the lock is let go
+func (e *Event) Ports() map[int][]Cve {
+	e.loadMu.Lock()
+	defer e.loadMu.Unlock()
+	return e.ports
+}
+
+// what is listening on each port, ports shodan lists without a banner have no entry
+func (e *Event) Services() map[int]Service {
+	e.loadMu.Lock()
+	defer e.loadMu.Unlock()
+	return e.services
+}
+
+// reports if the event has finished loading without blocking
+func (e *Event) Loaded() bool {
+	select {
+	case <-e.doneChan():
+		return true
+	default:
+		return false
 	}
-	defer response.Body.Close()
+}
 
-	body, err := io.ReadAll(response.Body)
-	if err != nil {
-		e.AlertId = fmt.Sprintf("Error reading alert id: %s", err.Error())
-		return
+// Wait blocks until the event has finished loading or the context ends
+func (e *Event) Wait(ctx context.Context) error {
+	select {
+	case <-e.doneChan():
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
 	}
+}
 
-	splitData := strings.Split(string(body), "let data =")
-	e.AlertId = strings.Split(splitData[1], "\"")[3]
+func (e *Event) doneChan() chan struct{} {
+	e.loadMu.Lock()
+	defer e.loadMu.Unlock()
+	return e.done
 }
 
-func (e *Event) getName(retries int) {
-	url := "https://api.shodan.io/shodan/alert/" + e.AlertId + "/info?key=" + os.Getenv("API_KEY")
-	response, err := http.Get(url)
+// takes the alert id and organization name from the network alert covering the event's ip
+func (e *Event) matchAlert(index *AlertIndex, err error) {
 	if err != nil {
-		e.Name = "Could not get name: " + err.Error()
+		e.addError(StageAlertId, err)
 		return
 	}
 
-	if response.StatusCode == http.StatusTooManyRequests {
-		if retries == 5 {
-			e.Name = fmt.Sprintf("http response error: %s", response.Status)
-		} else {
-			time.Sleep(time.Second * time.Duration((retries + 1)))
-			e.getName(retries + 1)
-		}
-		return
-	}
-	if response.StatusCode != http.StatusOK {
-		e.Name = fmt.Sprintf("http response error: %s", response.Status)
+	alert := index.Match(e.Ip, e.Trigger)
+	if alert == nil {
+		e.addError(StageAlertId, fmt.Errorf("no network alert covers %s", e.Ip))
 		return
 	}
-	defer response.Body.Close()
-	body, _ := io.ReadAll(response.Body)
-
-	alertString := make(map[string]string)
-	json.Unmarshal(body, &alertString)
 
-	e.Name = alertString["name"]
+	e.loadMu.Lock()
+	e.alertId = alert.Id
+	e.name = alert.Name
+	e.loadMu.Unlock()
 }
 
-func (e *Event) getBanner(retries int) Banner {
-	url := "https://api.shodan.io/shodan/host/" + e.Ip + "?key=" + os.Getenv("API_KEY")
-	response, err := http.Get(url)
+// the cves and services on each port of the host, both empty when it has none or fails to load
+func (e *Event) loadBanner(client ShodanClient) (map[int][]Cve, map[int]Service) {
+	banner, err := e.getBanner(client)
+
+	// shodan answers 404 when it has nothing indexed for the host, which is not a failure
+	var status *StatusError
+	if errors.As(err, &status) && status.Code == http.StatusNotFound {
+		return make(map[int][]Cve), make(map[int]Service)
+	}
 	if err != nil {
-		return Banner{}
+		e.addError(StageBanner, err)
+		return make(map[int][]Cve), make(map[int]Service)
 	}
+	return parseCves(banner), parseServices(banner)
+}
 
-	if response.StatusCode == http.StatusTooManyRequests {
-		if retries == 5 {
-			return Banner{}
-		} else {
-			time.Sleep(time.Second * time.Duration((retries + 1)))
-			return e.getBanner(retries + 1)
-		}
-	}
-	if response.StatusCode != http.StatusOK {
-		return Banner{}
+func (e *Event) getBanner(client ShodanClient) (Banner, error) {
+	body, err := client.Host(e.Ip)
+	if err != nil {
+		return Banner{}, err
 	}
-	defer response.Body.Close()
-	body, _ := io.ReadAll(response.Body)
 
 	banner := Banner{}
+	err = json.Unmarshal(body, &banner)
 
-	json.Unmarshal(body, &banner)
-
-	return banner
+	return banner, err
 }
 
-func (e *Event) parseCves(banner Banner) {
+func parseCves(banner Banner) map[int][]Cve {
+	ports := make(map[int][]Cve)
 	for _, p := range banner.Ports {
-		e.Ports[p] = []Cve{}
+		ports[p] = []Cve{}
 	}
 	for _, d := range banner.Data {
+		scanned, _ := time.Parse(shodanTimeLayout, d.Timestamp)
 		for name, vuln := range d.Vulns {
 			cve := NewCve(name, vuln, d.Cpe)
-			e.Ports[d.Port] = append(e.Ports[d.Port], cve)
+			cve.enrich(scanned)
+			ports[d.Port] = append(ports[d.Port], cve)
 		}
-		sort.Slice(e.Ports[d.Port], func(i, j int) bool {
-			return e.Ports[d.Port][i].Rank < e.Ports[d.Port][j].Rank
+		sort.Slice(ports[d.Port], func(i, j int) bool {
+			return ports[d.Port][i].Rank < ports[d.Port][j].Rank
 		})
 	}
+	return ports
 }
 
-// removes the ips with no cves
+// removes the ports with no cves, swapping in a new map so a reader of the old one
+// isn't changed under it
 func (e *Event) FilterCves() {
-	for key, value := range e.Ports {
-		if len(value) == 0 {
-			delete(e.Ports, key)
+	e.loadMu.Lock()
+	defer e.loadMu.Unlock()
+
+	ports := make(map[int][]Cve)
+	for key, value := range e.ports {
+		if len(value) > 0 {
+			ports[key] = value
 		}
 	}
+	e.ports = ports
 }
 
 type Vuln struct {
-	Cvss    float32 `json:"cvss,omitempty"`
-	CvssV2  float32 `json:"cvss_v2,omitempty"`
-	Epss    float32 `json:"epss,omitempty"`
-	Kev     bool    `json:"kev,omitempty"`
-	Summary string  `json:"summary,omitempty"`
+	Cvss         float32 `json:"cvss,omitempty"`
+	CvssVersion  float32 `json:"cvss_version,omitempty"`
+	CvssVector   string  `json:"cvss_vector,omitempty"`
+	CvssV2       float32 `json:"cvss_v2,omitempty"`
+	CvssV2Vector string  `json:"cvss_v2_vector,omitempty"`
+	CvssV3       float32 `json:"cvss_v3,omitempty"`
+	CvssV3Vector string  `json:"cvss_v3_vector,omitempty"`
+	CvssV4       float32 `json:"cvss_v4,omitempty"`
+	CvssV4Vector string  `json:"cvss_v4_vector,omitempty"`
+	Epss         float32 `json:"epss,omitempty"`
+	Kev          bool    `json:"kev,omitempty"`
+	Summary      string  `json:"summary,omitempty"`
 }
 
 type Banner struct {
-	Data []struct {
-		Port    int             `json:"port,omitempty"`
-		Vulns   map[string]Vuln `json:"vulns,omitempty"`
-		Product string          `json:"product,omitempty"`
-		Cpe     []string        `json:"cpe23,omitempty"`
-	} `json:"data"`
-	Ports []int `json:"ports"`
-}
-
-type Item struct {
-	Text        string `xml:",chardata"`
-	Title       string `xml:"title"`
-	Link        string `xml:"link"`
-	Description string `xml:"description"`
-	Guid        struct {
-		Text string `xml:",chardata"`
-	} `xml:"guid"`
-	PubDate string `xml:"pubDate"`
-}
-
-type Rss struct {
-	Channel struct {
-		Item []Item `xml:"item"`
-	} `xml:"channel"`
-}
-
-func DownloadRss() []*Event {
-	cache := NewEventCache()
-
-	apiKey := os.Getenv("API_KEY")
-	response, _ := http.Get("https://monitor.shodan.io/events.rss?key=" + apiKey)
-
-	if response.StatusCode != http.StatusOK {
-		fmt.Printf("Error: received status code %d\n", response.StatusCode)
-		return []*Event{}
-	}
-	defer response.Body.Close()
-
-	var rss Rss
-
-	decoder := xml.NewDecoder(response.Body)
-	decoder.Decode(&rss)
-
-	events := []*Event{}
-
-	// checks if an event has been seen recently, and if now add it to the list
-	for _, item := range rss.Channel.Item {
-		newEvent := NewEventFromItem(item)
-		if cache.HasEventBeenSeen(&newEvent) == false {
-			events = append(events, &newEvent)
-			cache.InsertEvent(&newEvent)
-		}
-	}
-
-	return events
+	Data  []ServiceBanner `json:"data"`
+	Ports []int           `json:"ports"`
 }
 
 type Net struct {
@@ -278,43 +321,76 @@ type Net struct {
 		Isp       string `json:"isp,omitempty"`
 		Os        string `json:"os,omitempty"`
 		Transport string `json:"transport,omitempty"`
-		Port      string `json:"port,omitempty"`
+		Port      int    `json:"port,omitempty"`
 		Ip        string `json:"ip_str,omitempty"`
 	} `json:"matches,omitempty"`
+	Total     int  `json:"total,omitempty"`
+	Truncated bool `json:"-"`
+	// the error that stopped the download, the matches before it are kept
+	Err error `json:"-"`
 }
 
-func DownloadMatches(queries string) Net {
-	apiKey := os.Getenv("API_KEY")
-	parts := strings.FieldsFunc(queries, func(r rune) bool {
-		return r == ',' || r == ' '
-	})
-	queries = strings.Join(parts, ",")
-	queries = strings.ReplaceAll(queries, " ", "")
+// a shodan search page holds at most 100 matches, and every page after the first costs a query credit
+const searchPageSize = 100
 
-	url := "https://api.shodan.io/shodan/host/search?key=" + apiKey + "&query=net:" + queries
-	response, err := http.Get(url)
-	if err != nil {
-		return Net{}
+// explains why the matches do not cover the whole search, or is empty when they do
+func (n *Net) Warning() string {
+	if n.Err != nil && len(n.Matches) == 0 {
+		return "Shodan search failed: " + n.Err.Error()
+	}
+	if n.Err != nil {
+		return fmt.Sprintf("Only %d of %d Shodan search results were downloaded, search failed: %s", len(n.Matches), n.Total, n.Err.Error())
 	}
+	if !n.Truncated {
+		return ""
+	}
+	return fmt.Sprintf("Only %d of %d Shodan search results were downloaded", len(n.Matches), n.Total)
+}
 
-	if response.StatusCode != http.StatusOK {
-		fmt.Printf("Error: received status code %d\n", response.StatusCode)
-		return Net{}
+// SHODAN_MAX_PAGES caps how many search pages are downloaded, 0 or unset downloads every page
+func maxSearchPages() int {
+	pages, err := strconv.Atoi(os.Getenv("SHODAN_MAX_PAGES"))
+	if err != nil || pages < 0 {
+		return 0
 	}
-	defer response.Body.Close()
+	return pages
+}
 
-	body, _ := io.ReadAll(response.Body)
+func DownloadMatches(client ShodanClient, query string) Net {
+	maxPages := maxSearchPages()
 	net := Net{}
-	json.Unmarshal(body, &net)
+
+	for page := 1; ; page++ {
+		body, err := client.Search(query, page)
+		if err != nil {
+			net.Err = err
+			net.Truncated = page > 1
+			break
+		}
+
+		pageNet := Net{}
+		json.Unmarshal(body, &pageNet)
+		net.Total = pageNet.Total
+		net.Matches = append(net.Matches, pageNet.Matches...)
+
+		if len(pageNet.Matches) < searchPageSize || len(net.Matches) >= net.Total {
+			break
+		}
+		if maxPages > 0 && page >= maxPages {
+			net.Truncated = true
+			break
+		}
+	}
+
 	return net
 }
 
-func DownloadIpList(name string, queries string) []*Event {
-	if queries == "" {
-		return []*Event{}
+// downloads every host matching the query, along with a warning if the search was truncated
+func DownloadIpList(client ShodanClient, name string, query string) ([]*Event, string) {
+	if query == "" {
+		return []*Event{}, ""
 	}
-	net := DownloadMatches(queries)
-	var wg sync.WaitGroup
+	net := DownloadMatches(client, query)
 	events := []*Event{}
 
 outer:
@@ -327,28 +403,37 @@ outer:
 				continue outer
 			}
 		}
-		events = append(events, &newEvent)
+		events = append(events, newEvent)
+	}
+
+	// loads each event after parsing their ip
+	SharedLimiter().ForEach(len(events), func(i int) {
+		events[i].Load(client)
+	})
 
-		// loads each event after parsing their ip
-		wg.Add(1)
-		go func(e *Event, wg *sync.WaitGroup) {
-			defer wg.Done()
+	return events, net.Warning()
+}
 
-			banner := e.getBanner(0)
-			e.parseCves(banner)
-			e.Loaded = true
-		}(&newEvent, &wg)
+// describes every event that failed to load, so a report built from them can warn that it is missing data
+func IncompleteWarning(events []*Event) string {
+	failed := []string{}
+	for _, e := range events {
+		for _, err := range e.Errors() {
+			failed = append(failed, e.Ip+" ("+err.Error()+")")
+		}
 	}
-	wg.Wait()
 
-	return events
+	if len(failed) == 0 {
+		return ""
+	}
+	return "Data failed to load for " + strings.Join(failed, ", ") + ". The report is missing findings for these hosts."
 }
 
 // filters events that have no ports available
 func FilterEvents(events []*Event) []*Event {
 	newEventList := []*Event{}
 	for _, e := range events {
-		if len(e.Ports) > 0 {
+		if len(e.Ports()) > 0 {
 			newEventList = append(newEventList, e)
 		}
 	}
@@ -362,7 +447,7 @@ func FilterCveEvents(events []*Event) []*Event {
 
 	for _, e := range events {
 		hasCve := false
-		for _, value := range e.Ports {
+		for _, value := range e.Ports() {
 			if len(value) > 0 {
 				hasCve = true
 				break
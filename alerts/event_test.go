@@ -0,0 +1,112 @@
+package alerts
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// a client answering host lookups from memory, every other call panics on the nil client.
+// Lookups wait on gate when it is set, so a load can be held open
+type fakeClient struct {
+	ShodanClient
+	host    string
+	gate    chan struct{}
+	started chan struct{}
+	lookups atomic.Int32
+}
+
+func (f *fakeClient) Host(ip string) ([]byte, error) {
+	f.lookups.Add(1)
+	if f.started != nil {
+		f.started <- struct{}{}
+	}
+	if f.gate != nil {
+		<-f.gate
+	}
+	return []byte(f.host), nil
+}
+
+const fakeHost = `{"ports": [22, 80], "data": [
+	{"port": 22, "transport": "tcp", "product": "OpenSSH", "version": "9.6"},
+	{"port": 80, "transport": "tcp", "product": "nginx", "version": "1.26.0"}
+]}`
+
+// reads every loaded field the templates use, so the race detector sees the reads
+func readEvent(t *testing.T, e *Event) {
+	if err := e.Wait(context.Background()); err != nil {
+		t.Error(err)
+		return
+	}
+	if len(e.Ports()) != 2 || e.ServiceLabel(22) == "" || e.Incomplete() {
+		t.Errorf("loaded event has ports %v and errors %v", e.Ports(), e.Errors())
+	}
+}
+
+func TestEventConcurrentLoad(t *testing.T) {
+	client := &fakeClient{host: fakeHost}
+	e := NewEventFromIp("192.0.2.1")
+
+	wg := sync.WaitGroup{}
+	for i := 0; i < 10; i++ {
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			e.Load(client)
+		}()
+		go func() {
+			defer wg.Done()
+			readEvent(t, e)
+		}()
+	}
+	wg.Wait()
+
+	if lookups := client.lookups.Load(); lookups != 1 {
+		t.Errorf("the host was looked up %d times, want once", lookups)
+	}
+}
+
+func TestEventConcurrentRetry(t *testing.T) {
+	client := &fakeClient{host: fakeHost}
+	e := NewEventFromIp("192.0.2.1")
+	e.Load(client)
+	readEvent(t, e)
+
+	// hold the retry open, so every other call lands while it runs
+	client.gate = make(chan struct{})
+	client.started = make(chan struct{}, 1)
+	go e.Retry(client)
+	<-client.started
+	if e.Loaded() {
+		t.Fatal("the event is loaded while its retry is still running")
+	}
+
+	wg := sync.WaitGroup{}
+	for i := 0; i < 10; i++ {
+		wg.Add(3)
+		go func() {
+			defer wg.Done()
+			e.Retry(client)
+			readEvent(t, e)
+		}()
+		go func() {
+			defer wg.Done()
+			e.Load(client)
+		}()
+		go func() {
+			defer wg.Done()
+			readEvent(t, e)
+		}()
+	}
+	// give the calls time to land before the retry finishes
+	time.Sleep(100 * time.Millisecond)
+	close(client.gate)
+	wg.Wait()
+
+	// the calls made during the retry joined it rather than starting their own
+	if lookups := client.lookups.Load(); lookups != 2 {
+		t.Errorf("the host was looked up %d times, want twice", lookups)
+	}
+}
@@ -0,0 +1,259 @@
+package alerts
+
+import (
+	"bufio"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/netip"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+)
+
+// a banner of a shodan download file, the same banners the search and host apis return
+type exportBanner struct {
+	ServiceBanner
+	Ip string `json:"ip_str"`
+
+	addr    netip.Addr
+	scanned time.Time
+	raw     json.RawMessage
+}
+
+// ExportClient answers host lookups and searches from a `shodan download` file instead of
+// the network, so reports can be built from an export with no api key
+type ExportClient struct {
+	Name    string
+	banners []exportBanner
+	// the banners of each ip, by index into banners
+	hosts map[string][]int
+}
+
+var ErrExportOnly = errors.New("only hosts and searches can be read from an imported shodan export")
+
+// the most an export is read to once decompressed, every banner is held in memory
+var maxExportSize int64 = 1 << 30
+
+// ReadExport reads the newline delimited banners of a shodan download file, gzipped or not.
+// Exports larger than maxExportSize once decompressed are refused
+func ReadExport(name string, file io.Reader) (*ExportClient, error) {
+	reader := bufio.NewReader(file)
+
+	var input io.Reader = reader
+	if magic, _ := reader.Peek(2); len(magic) == 2 && magic[0] == 0x1f && magic[1] == 0x8b {
+		unzipped, err := gzip.NewReader(reader)
+		if err != nil {
+			return nil, err
+		}
+		defer unzipped.Close()
+		input = unzipped
+	}
+
+	export := &ExportClient{Name: name, hosts: make(map[string][]int)}
+
+	limited := &io.LimitedReader{R: input, N: maxExportSize + 1}
+	tooLarge := func() error {
+		return fmt.Errorf("%s is larger than %d MB once decompressed", name, maxExportSize>>20)
+	}
+
+	scanner := bufio.NewScanner(limited)
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+	for line := 1; scanner.Scan(); line++ {
+		raw := scanner.Bytes()
+		if len(strings.TrimSpace(string(raw))) == 0 {
+			continue
+		}
+
+		banner := exportBanner{}
+		if err := json.Unmarshal(raw, &banner); err != nil {
+			if limited.N <= 0 {
+				return nil, tooLarge()
+			}
+			return nil, fmt.Errorf("%s line %d: %w", name, line, err)
+		}
+
+		addr, err := netip.ParseAddr(banner.Ip)
+		if err != nil {
+			return nil, fmt.Errorf("%s line %d: invalid ip %q", name, line, banner.Ip)
+		}
+		banner.addr = addr.Unmap()
+		banner.Ip = banner.addr.String()
+		banner.scanned, _ = time.Parse(shodanTimeLayout, banner.Timestamp)
+		banner.raw = append(json.RawMessage{}, raw...)
+
+		export.hosts[banner.Ip] = append(export.hosts[banner.Ip], len(export.banners))
+		export.banners = append(export.banners, banner)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("%s: %w", name, err)
+	}
+	if limited.N <= 0 {
+		return nil, tooLarge()
+	}
+
+	if len(export.banners) == 0 {
+		return nil, fmt.Errorf("%s has no shodan banners", name)
+	}
+
+	return export, nil
+}
+
+// Scope covers every host in the export
+func (x *ExportClient) Scope() Scope {
+	ranges := []AddrRange{}
+	for _, banner := range x.banners {
+		ranges = append(ranges, AddrRange{From: banner.addr, To: banner.addr})
+	}
+	return Scope{Ranges: mergeRanges(ranges)}
+}
+
+// the newest banner of each port and transport of the host, like /shodan/host gives
+func (x *ExportClient) Host(ip string) ([]byte, error) {
+	if addr, err := netip.ParseAddr(ip); err == nil {
+		ip = addr.Unmap().String()
+	}
+
+	latest := make(map[string]ServiceBanner)
+	scanned := make(map[string]time.Time)
+	for _, i := range x.hosts[ip] {
+		banner := x.banners[i]
+		key := fmt.Sprint(banner.Port, "/", banner.Transport)
+		if last, ok := scanned[key]; ok && !banner.scanned.After(last) {
+			continue
+		}
+		latest[key] = banner.ServiceBanner
+		scanned[key] = banner.scanned
+	}
+
+	if len(latest) == 0 {
+		return nil, &StatusError{Code: http.StatusNotFound, Status: "404 Not Found"}
+	}
+
+	host := Banner{}
+	for _, banner := range latest {
+		host.Data = append(host.Data, banner)
+		if !slices.Contains(host.Ports, banner.Port) {
+			host.Ports = append(host.Ports, banner.Port)
+		}
+	}
+	sort.Ints(host.Ports)
+	sort.Slice(host.Data, func(i, j int) bool {
+		return host.Data[i].Port < host.Data[j].Port
+	})
+
+	return json.Marshal(host)
+}
+
+// the banners matching the query, a page at a time like /shodan/host/search
+func (x *ExportClient) Search(query string, page int) ([]byte, error) {
+	parsed, err := ParseQuery(query)
+	if err != nil {
+		return nil, err
+	}
+
+	matches := []json.RawMessage{}
+	for _, banner := range x.banners {
+		if banner.matches(parsed) {
+			matches = append(matches, banner.raw)
+		}
+	}
+
+	total := len(matches)
+	start := min((page-1)*searchPageSize, total)
+	end := min(start+searchPageSize, total)
+
+	return json.Marshal(struct {
+		Matches []json.RawMessage `json:"matches"`
+		Total   int               `json:"total"`
+	}{
+		Matches: matches[start:end],
+		Total:   total,
+	})
+}
+
+// true when the banner matches every filter of the query and none of the negated ones
+func (b exportBanner) matches(query Query) bool {
+	for _, filter := range query.Filters {
+		matched := false
+		for _, value := range filter.Values {
+			if b.matchesFilter(filter.Name, value) {
+				matched = true
+				break
+			}
+		}
+		if matched == filter.Negated {
+			return false
+		}
+	}
+	return true
+}
+
+// matches a filter close to how shodan does, names are matched without case,
+// orgs by a part of the name and hostnames along with their subdomains
+func (b exportBanner) matchesFilter(name string, value string) bool {
+	switch name {
+	case "net":
+		if prefix, err := netip.ParsePrefix(value); err == nil {
+			return prefix.Contains(b.addr)
+		}
+		addr, err := netip.ParseAddr(value)
+		return err == nil && addr.Unmap() == b.addr
+	case "org":
+		return strings.Contains(strings.ToLower(b.Org), strings.ToLower(value))
+	case "hostname":
+		for _, hostname := range b.Hostnames {
+			hostname = strings.ToLower(hostname)
+			if hostname == value || strings.HasSuffix(hostname, "."+value) {
+				return true
+			}
+		}
+		return false
+	case "ssl.cert.subject.cn":
+		return b.Ssl != nil && strings.EqualFold(b.Ssl.Cert.Subject.Cn, value)
+	case "asn":
+		return strings.EqualFold(b.Asn, value)
+	}
+	return false
+}
+
+func (x *ExportClient) Rss() ([]byte, error) {
+	return nil, ErrExportOnly
+}
+
+func (x *ExportClient) Alerts() ([]byte, error) {
+	return nil, ErrExportOnly
+}
+
+func (x *ExportClient) Stream(ctx context.Context) (io.ReadCloser, error) {
+	return nil, ErrExportOnly
+}
+
+func (x *ExportClient) Triggers() ([]byte, error) {
+	return nil, ErrExportOnly
+}
+
+func (x *ExportClient) CreateAlert(body []byte) ([]byte, error) {
+	return nil, ErrExportOnly
+}
+
+func (x *ExportClient) UpdateAlert(id string, body []byte) ([]byte, error) {
+	return nil, ErrExportOnly
+}
+
+func (x *ExportClient) DeleteAlert(id string) error {
+	return ErrExportOnly
+}
+
+func (x *ExportClient) EnableTrigger(id string, trigger string) error {
+	return ErrExportOnly
+}
+
+func (x *ExportClient) DisableTrigger(id string, trigger string) error {
+	return ErrExportOnly
+}
@@ -0,0 +1,154 @@
+package alerts
+
+import (
+	"bytes"
+	"compress/gzip"
+	"slices"
+	"strings"
+	"testing"
+)
+
+// banners of a shodan download file, 192.0.2.1 was scanned twice on port 22
+const testExport = `{"ip_str": "192.0.2.1", "port": 22, "transport": "tcp", "product": "OpenSSH", "version": "7.4", "asn": "AS64500", "hostnames": ["mail.example.com"], "timestamp": "2026-09-01T10:00:00.000000"}
+{"ip_str": "192.0.2.1", "port": 22, "transport": "tcp", "product": "OpenSSH", "version": "8.9", "asn": "AS64500", "hostnames": ["mail.example.com"], "timestamp": "2026-10-01T10:00:00.000000"}
+{"ip_str": "192.0.2.1", "port": 161, "transport": "udp", "asn": "AS64500", "hostnames": ["mail.example.com"], "timestamp": "2026-10-01T10:00:00.000000"}
+
+{"ip_str": "192.0.2.2", "port": 443, "transport": "tcp", "asn": "AS64501", "hostnames": ["www.example.org"], "timestamp": "2026-10-01T10:00:00.000000"}
+{"ip_str": "2001:db8::1", "port": 80, "transport": "tcp", "asn": "AS64500", "timestamp": "2026-10-01T10:00:00.000000"}
+`
+
+func gzipped(t *testing.T, text string) []byte {
+	t.Helper()
+
+	buffer := bytes.Buffer{}
+	writer := gzip.NewWriter(&buffer)
+	writer.Write([]byte(text))
+	if err := writer.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buffer.Bytes()
+}
+
+func readTestExport(t *testing.T) *ExportClient {
+	t.Helper()
+
+	export, err := ReadExport("export.json.gz", bytes.NewReader(gzipped(t, testExport)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return export
+}
+
+func eventIps(events []*Event) []string {
+	ips := []string{}
+	for _, e := range events {
+		ips = append(ips, e.Ip)
+	}
+	slices.Sort(ips)
+	return ips
+}
+
+func TestReadExport(t *testing.T) {
+	export := readTestExport(t)
+	if got := export.Scope().Strings(); !slices.Equal(got, []string{"192.0.2.1-192.0.2.2", "2001:db8::1"}) {
+		t.Errorf("export covers %v", got)
+	}
+
+	// the same file without gzip reads the same
+	plain, err := ReadExport("export.json", strings.NewReader(testExport))
+	if err != nil || len(plain.banners) != len(export.banners) {
+		t.Errorf("plain export read %d banners with error %v, want %d", len(plain.banners), err, len(export.banners))
+	}
+
+	for _, bad := range []string{"", "\n\n", `{"ip_str": "192.0.2"}`, `{"ip_str": `} {
+		if _, err := ReadExport("bad.json", strings.NewReader(bad)); err == nil {
+			t.Errorf("%q read without an error", bad)
+		}
+	}
+}
+
+func TestReadExportTooLarge(t *testing.T) {
+	defer func(size int64) { maxExportSize = size }(maxExportSize)
+	maxExportSize = int64(len(testExport)) - 1
+
+	_, err := ReadExport("export.json.gz", bytes.NewReader(gzipped(t, testExport)))
+	if err == nil || !strings.Contains(err.Error(), "larger than") {
+		t.Errorf("an export past the size limit gave %v", err)
+	}
+}
+
+func TestExportHost(t *testing.T) {
+	export := readTestExport(t)
+
+	events, warning := DownloadIpList(export, "", "net:192.0.2.1")
+	if warning != "" || len(events) != 1 {
+		t.Fatalf("got %d events with warning %q, want 1", len(events), warning)
+	}
+
+	e := events[0]
+	if e.Incomplete() || len(e.Ports()) != 2 {
+		t.Fatalf("192.0.2.1 has ports %v and errors %v, want 22 and 161", e.Ports(), e.Errors())
+	}
+	// only the newest scan of each port is kept
+	if service := e.Services()[22]; service.Version != "8.9" {
+		t.Errorf("port 22 is version %q, want the newer 8.9", service.Version)
+	}
+	if service := e.Services()[161]; service.Transport != "udp" {
+		t.Errorf("port 161 is %q, want udp", service.Transport)
+	}
+
+	// a host missing from the export is an empty host, not a failure
+	missing := NewEventFromIp("192.0.2.9")
+	missing.Load(export)
+	if missing.Incomplete() || len(missing.Ports()) != 0 {
+		t.Errorf("192.0.2.9 has ports %v and errors %v, want an empty host", missing.Ports(), missing.Errors())
+	}
+}
+
+func TestExportSearch(t *testing.T) {
+	export := readTestExport(t)
+
+	tests := []struct {
+		query string
+		ips   []string
+	}{
+		{"net:192.0.2.0/24", []string{"192.0.2.1", "192.0.2.2"}},
+		{"net:192.0.2.2,2001:db8::/32", []string{"192.0.2.2", "2001:db8::1"}},
+		{"hostname:example.com", []string{"192.0.2.1"}},
+		{"hostname:www.example.org", []string{"192.0.2.2"}},
+		{"hostname:example.net", []string{}},
+		{"asn:AS64500", []string{"192.0.2.1", "2001:db8::1"}},
+		{"asn:64501", []string{"192.0.2.2"}},
+		{"asn:AS64500 net:192.0.2.0/24", []string{"192.0.2.1"}},
+		{"asn:AS64500 -net:192.0.2.0/24", []string{"2001:db8::1"}},
+		{"-hostname:example.com,example.org", []string{"2001:db8::1"}},
+	}
+
+	for _, test := range tests {
+		net := DownloadMatches(export, test.query)
+		if net.Err != nil {
+			t.Errorf("%q: %v", test.query, net.Err)
+			continue
+		}
+
+		ips := []string{}
+		for _, match := range net.Matches {
+			if !slices.Contains(ips, match.Ip) {
+				ips = append(ips, match.Ip)
+			}
+		}
+		slices.Sort(ips)
+		if !slices.Equal(ips, test.ips) {
+			t.Errorf("%q matched %v, want %v", test.query, ips, test.ips)
+		}
+	}
+
+	events, _ := DownloadIpList(export, "", "-asn:AS64500")
+	if ips := eventIps(events); !slices.Equal(ips, []string{"192.0.2.2"}) {
+		t.Errorf("-asn:AS64500 gave events for %v, want 192.0.2.2", ips)
+	}
+
+	if net := DownloadMatches(export, "port:22"); net.Err == nil {
+		t.Error("a filter the export can't match searched without an error")
+	}
+}
@@ -1,7 +1,7 @@
 package alerts
 
 import (
-	"time"
+	"context"
 )
 
 type Feed struct {
@@ -9,8 +9,8 @@ type Feed struct {
 	Index int
 }
 
-func NewFeed() Feed {
-	events := DownloadRss()
+func NewFeed(client ShodanClient) Feed {
+	events, _ := DownloadRss(client)
 	return Feed{
 		events: events,
 		Index: 0,
@@ -23,9 +23,7 @@ func (f *Feed) Next() *Event {
 }
 
 func (f *Feed) GetEvent() *Event {
-	for f.events[f.Index].Loaded != true {
-		time.Sleep(1)
-	}
+	f.events[f.Index].Wait(context.Background())
 
 	return f.events[f.Index]
 }
@@ -0,0 +1,164 @@
+package alerts
+
+import (
+	"database/sql"
+	"fmt"
+	"os"
+	"strconv"
+	"time"
+
+	_ "modernc.org/sqlite"
+)
+
+const defaultHostCacheTtl = 24 * time.Hour
+
+// HostCache keeps the raw /shodan/host response for each ip so repeated lookups don't spend query credits
+type HostCache struct {
+	db *sql.DB
+}
+
+func NewHostCache() *HostCache {
+	db, err := sql.Open("sqlite", "./resources/event_cache.db")
+	if err != nil {
+		panic("Missing Resoruces")
+	}
+
+	cache := &HostCache{
+		db: db,
+	}
+
+	cache.ensureTable()
+	cache.prune(HostCacheTtl())
+	return cache
+}
+
+func (h *HostCache) ensureTable() {
+	h.db.Exec(`CREATE TABLE IF NOT EXISTS hosts(
+ip TEXT PRIMARY KEY,
+body BLOB,
+fetched INTEGER
+)`)
+}
+
+// returns the cached response if it was fetched within the ttl
+func (h *HostCache) Get(ip string, ttl time.Duration) ([]byte, bool) {
+	var body []byte
+	var fetched int64
+
+	err := h.db.QueryRow(`SELECT body, fetched FROM hosts WHERE ip = ?`, ip).Scan(&body, &fetched)
+	if err != nil {
+		return nil, false
+	}
+
+	if time.Since(time.Unix(fetched, 0)) > ttl {
+		return nil, false
+	}
+
+	return body, true
+}
+
+func (h *HostCache) Put(ip string, body []byte) {
+	_, err := h.db.Exec(`INSERT INTO hosts(ip, body, fetched) VALUES (?,?,?)
+ON CONFLICT(ip) DO UPDATE SET body = excluded.body, fetched = excluded.fetched`, ip, body, time.Now().Unix())
+	if err != nil {
+		fmt.Println("insert", err.Error())
+	}
+}
+
+// deletes the hosts fetched longer ago than the ttl, they are never used again
+func (h *HostCache) prune(ttl time.Duration) {
+	_, err := h.db.Exec(`DELETE FROM hosts WHERE fetched < ?`, time.Now().Add(-ttl).Unix())
+	if err != nil {
+		fmt.Println("pruning", err.Error())
+	}
+}
+
+func (h *HostCache) ClearTable() {
+	h.db.Exec(`DELETE FROM hosts`)
+}
+
+type HostCacheStats struct {
+	Hosts  int
+	Bytes  int64
+	Oldest time.Time
+	Newest time.Time
+	Ttl    time.Duration
+}
+
+func (h *HostCache) Stats() HostCacheStats {
+	stats := HostCacheStats{Ttl: HostCacheTtl()}
+	var oldest, newest sql.NullInt64
+
+	err := h.db.QueryRow(`SELECT COUNT(*), COALESCE(SUM(LENGTH(body)), 0), MIN(fetched), MAX(fetched) FROM hosts`).Scan(&stats.Hosts, &stats.Bytes, &oldest, &newest)
+	if err != nil {
+		fmt.Println("querying", err.Error())
+		return stats
+	}
+
+	if oldest.Valid {
+		stats.Oldest = time.Unix(oldest.Int64, 0)
+		stats.Newest = time.Unix(newest.Int64, 0)
+	}
+
+	return stats
+}
+
+// HOST_CACHE_TTL is how many hours a cached host is used for, defaults to 24
+func HostCacheTtl() time.Duration {
+	hours, err := strconv.ParseFloat(os.Getenv("HOST_CACHE_TTL"), 64)
+	if err != nil || hours < 0 {
+		return defaultHostCacheTtl
+	}
+	return time.Duration(hours * float64(time.Hour))
+}
+
+// CachedClient answers host lookups from the host cache and sends everything else to the wrapped client
+type CachedClient struct {
+	ShodanClient
+	cache   *HostCache
+	ttl     time.Duration
+	refresh bool
+}
+
+func NewCachedClient(client ShodanClient) *CachedClient {
+	return &CachedClient{
+		ShodanClient: client,
+		cache:        NewHostCache(),
+		ttl:          HostCacheTtl(),
+	}
+}
+
+func (c *CachedClient) Host(ip string) ([]byte, error) {
+	if !c.refresh {
+		if body, ok := c.cache.Get(ip, c.ttl); ok {
+			return body, nil
+		}
+	}
+
+	body, err := c.ShodanClient.Host(ip)
+	if err != nil {
+		return body, err
+	}
+
+	c.cache.Put(ip, body)
+	return body, nil
+}
+
+// a copy of the client that always fetches hosts again, still saving them to the cache
+func (c *CachedClient) ForceRefresh() ShodanClient {
+	refreshed := *c
+	refreshed.refresh = true
+	return &refreshed
+}
+
+type refresher interface {
+	ForceRefresh() ShodanClient
+}
+
+// ForceRefresh skips the host cache for the requests made with the returned client
+func ForceRefresh(client ShodanClient) ShodanClient {
+	if r, ok := client.(refresher); ok {
+		return r.ForceRefresh()
+	}
+	return client
+}
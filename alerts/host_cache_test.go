@@ -0,0 +1,25 @@
+package alerts
+
+import (
+	"testing"
+	"time"
+)
+
+func TestHostCachePrune(t *testing.T) {
+	t.Setenv("HOST_CACHE_TTL", "24")
+
+	cache := NewHostCache()
+	cache.ClearTable()
+	cache.Put("192.0.2.1", []byte(`{}`))
+	cache.Put("192.0.2.2", []byte(`{}`))
+	cache.db.Exec(`UPDATE hosts SET fetched = ? WHERE ip = ?`, time.Now().Add(-25*time.Hour).Unix(), "192.0.2.2")
+
+	// opening the cache again drops the host past its ttl and keeps the fresh one
+	cache = NewHostCache()
+	if stats := cache.Stats(); stats.Hosts != 1 {
+		t.Errorf("the cache holds %d hosts, want 1", stats.Hosts)
+	}
+	if _, ok := cache.Get("192.0.2.1", HostCacheTtl()); !ok {
+		t.Error("the fresh host was pruned")
+	}
+}
@@ -0,0 +1,100 @@
+package alerts
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+)
+
+// KevEntry is the part of a CISA known exploited vulnerability listing that ends up in reports
+type KevEntry struct {
+	CveId          string `json:"cveID"`
+	DateAdded      string `json:"dateAdded"`
+	DueDate        string `json:"dueDate"`
+	RequiredAction string `json:"requiredAction"`
+	Ransomware     string `json:"knownRansomwareCampaignUse"`
+}
+
+// true when CISA knows of the vulnerability being used in a ransomware campaign
+func (k KevEntry) KnownRansomware() bool {
+	return k.Ransomware == "Known"
+}
+
+type kevCatalogFile struct {
+	CatalogVersion  string     `json:"catalogVersion"`
+	Vulnerabilities []KevEntry `json:"vulnerabilities"`
+}
+
+func (v *VulnStore) ensureKevTables() {
+	v.db.Exec(`CREATE TABLE IF NOT EXISTS kev(
+cve TEXT PRIMARY KEY,
+date_added TEXT,
+due_date TEXT,
+required_action TEXT,
+ransomware TEXT
+)`)
+}
+
+// ImportKev replaces the stored catalog with the CISA KEV json file at path
+func (v *VulnStore) ImportKev(path string) (int, error) {
+	body, err := os.ReadFile(path)
+	if err != nil {
+		return 0, err
+	}
+
+	catalog := kevCatalogFile{}
+	if err := json.Unmarshal(body, &catalog); err != nil {
+		return 0, err
+	}
+	if len(catalog.Vulnerabilities) == 0 {
+		return 0, fmt.Errorf("%s has no vulnerabilities", path)
+	}
+
+	tx, err := v.db.Begin()
+	if err != nil {
+		return 0, err
+	}
+
+	if _, err := tx.Exec(`DELETE FROM kev`); err != nil {
+		tx.Rollback()
+		return 0, err
+	}
+
+	for _, entry := range catalog.Vulnerabilities {
+		_, err := tx.Exec(`INSERT OR REPLACE INTO kev(cve, date_added, due_date, required_action, ransomware) VALUES (?,?,?,?,?)`,
+			entry.CveId, entry.DateAdded, entry.DueDate, entry.RequiredAction, entry.Ransomware)
+		if err != nil {
+			tx.Rollback()
+			return 0, err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, err
+	}
+
+	reloadCatalogs()
+	return len(catalog.Vulnerabilities), nil
+}
+
+func (v *VulnStore) kevEntries() map[string]KevEntry {
+	entries := make(map[string]KevEntry)
+
+	rows, err := v.db.Query(`SELECT cve, date_added, due_date, required_action, ransomware FROM kev`)
+	if err != nil {
+		fmt.Println("querying", err.Error())
+		return entries
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		entry := KevEntry{}
+		if err := rows.Scan(&entry.CveId, &entry.DateAdded, &entry.DueDate, &entry.RequiredAction, &entry.Ransomware); err != nil {
+			fmt.Println("scanning", err.Error())
+			continue
+		}
+		entries[entry.CveId] = entry
+	}
+
+	return entries
+}
@@ -0,0 +1,62 @@
+package alerts
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+const testKev = `{
+	"catalogVersion": "2026.10.01",
+	"vulnerabilities": [
+		{"cveID": "CVE-2099-1001", "dateAdded": "2026-09-01", "dueDate": "2026-09-22", "requiredAction": "Apply mitigations per vendor instructions.", "knownRansomwareCampaignUse": "Known"},
+		{"cveID": "CVE-2099-1002", "dateAdded": "2026-09-15", "dueDate": "2026-10-06", "requiredAction": "Discontinue use of the product.", "knownRansomwareCampaignUse": "Unknown"}
+	]
+}`
+
+func TestImportKev(t *testing.T) {
+	store := NewVulnStore()
+
+	count, err := store.ImportKev(writeTestFile(t, "kev.json", []byte(testKev)))
+	if err != nil || count != 2 {
+		t.Fatalf("imported %d entries with error %v, want 2", count, err)
+	}
+	if entries := store.kevEntries(); len(entries) != 2 || entries["CVE-2099-1002"].DueDate != "2026-10-06" {
+		t.Errorf("stored %v", entries)
+	}
+
+	for _, bad := range []string{`{"vulnerabilities": []}`, `{"vulnerabilities": `} {
+		if _, err := store.ImportKev(writeTestFile(t, "kev.json", []byte(bad))); err == nil {
+			t.Errorf("%q imported without an error", bad)
+		}
+	}
+	if _, err := store.ImportKev(writeTestFile(t, "missing", nil) + ".json"); err == nil {
+		t.Error("a missing file imported without an error")
+	}
+}
+
+func TestEnrichKev(t *testing.T) {
+	if _, err := NewVulnStore().ImportKev(writeTestFile(t, "kev.json", []byte(testKev))); err != nil {
+		t.Fatal(err)
+	}
+	scanned := time.Date(2026, 10, 1, 0, 0, 0, 0, time.UTC)
+
+	cve := NewCve("CVE-2099-1001", Vuln{Cvss: 9.8}, nil)
+	cve.enrich(scanned)
+	if !cve.Kev || cve.KevDateAdded != "2026-09-01" || cve.KevDueDate != "2026-09-22" || !cve.KevRansomware || !strings.HasPrefix(cve.KevAction, "Apply mitigations") {
+		t.Errorf("enriched %+v", cve)
+	}
+
+	cve = NewCve("CVE-2099-1002", Vuln{Cvss: 9.8}, nil)
+	cve.enrich(scanned)
+	if !cve.Kev || cve.KevRansomware || cve.KevAction != "Discontinue use of the product." {
+		t.Errorf("enriched %+v", cve)
+	}
+
+	// a cve missing from the catalog keeps the flag shodan sent, with no details to add
+	cve = NewCve("CVE-2099-1003", Vuln{Cvss: 9.8, Kev: true}, nil)
+	cve.enrich(scanned)
+	if !cve.Kev || cve.KevAction != "" || cve.KevDueDate != "" {
+		t.Errorf("a cve missing from the catalog has kev details %+v", cve)
+	}
+}
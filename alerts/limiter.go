@@ -0,0 +1,141 @@
+package alerts
+
+import (
+	"math/rand"
+	"net/http"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+)
+
+const (
+	defaultRps     = 1.0
+	defaultWorkers = 4
+	maxRetries     = 5
+	maxBackoff     = time.Minute
+)
+
+// Limiter spaces out every request made against shodan and caps how many run at once
+type Limiter struct {
+	interval time.Duration
+	workers  chan struct{}
+
+	mu   sync.Mutex
+	next time.Time
+}
+
+func NewLimiter(rps float64, workers int) *Limiter {
+	if rps <= 0 {
+		rps = defaultRps
+	}
+	if workers <= 0 {
+		workers = defaultWorkers
+	}
+
+	return &Limiter{
+		interval: time.Duration(float64(time.Second) / rps),
+		workers:  make(chan struct{}, workers),
+	}
+}
+
+var (
+	sharedLimiter     *Limiter
+	sharedLimiterOnce sync.Once
+)
+
+// SharedLimiter is the process wide limiter, configured with SHODAN_RPS and SHODAN_WORKERS.
+// It is built on first use so the env file has been loaded by then
+func SharedLimiter() *Limiter {
+	sharedLimiterOnce.Do(func() {
+		rps, _ := strconv.ParseFloat(os.Getenv("SHODAN_RPS"), 64)
+		workers, _ := strconv.Atoi(os.Getenv("SHODAN_WORKERS"))
+		sharedLimiter = NewLimiter(rps, workers)
+	})
+	return sharedLimiter
+}
+
+// blocks until the next request is allowed to start
+func (l *Limiter) Wait() {
+	l.mu.Lock()
+	now := time.Now()
+	if l.next.Before(now) {
+		l.next = now
+	}
+	wait := l.next.Sub(now)
+	l.next = l.next.Add(l.interval)
+	l.mu.Unlock()
+
+	time.Sleep(wait)
+}
+
+// holds back every request for at least the duration, used after the api says to slow down
+func (l *Limiter) Pause(d time.Duration) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	resume := time.Now().Add(d)
+	if resume.After(l.next) {
+		l.next = resume
+	}
+}
+
+// Do sends a request through the limiter, retrying rate limited and unavailable responses.
+// Retry-After is honored when it is sent, otherwise it backs off exponentially with jitter
+func (l *Limiter) Do(request func() (*http.Response, error)) (*http.Response, error) {
+	l.workers <- struct{}{}
+	defer func() { <-l.workers }()
+
+	for attempt := 0; ; attempt++ {
+		l.Wait()
+		response, err := request()
+		if err != nil {
+			return nil, err
+		}
+
+		retryable := response.StatusCode == http.StatusTooManyRequests || response.StatusCode == http.StatusServiceUnavailable
+		if !retryable || attempt == maxRetries {
+			return response, nil
+		}
+
+		l.Pause(backoff(attempt, response.Header.Get("Retry-After")))
+		response.Body.Close()
+	}
+}
+
+// ForEach calls fn for every index with at most the limiter's worker count running at once
+func (l *Limiter) ForEach(n int, fn func(i int)) {
+	jobs := make(chan int)
+	var wg sync.WaitGroup
+
+	for w := 0; w < min(cap(l.workers), n); w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				fn(i)
+			}
+		}()
+	}
+
+	for i := 0; i < n; i++ {
+		jobs <- i
+	}
+	close(jobs)
+	wg.Wait()
+}
+
+func backoff(attempt int, retryAfter string) time.Duration {
+	if seconds, err := strconv.Atoi(retryAfter); err == nil {
+		return time.Duration(seconds) * time.Second
+	}
+	if date, err := http.ParseTime(retryAfter); err == nil {
+		return time.Until(date)
+	}
+
+	d := time.Second << attempt
+	if d > maxBackoff {
+		d = maxBackoff
+	}
+	return d/2 + time.Duration(rand.Int63n(int64(d/2)+1))
+}
@@ -0,0 +1,164 @@
+package alerts
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// a server answering each request with the next status, then 200 once they run out.
+// Retry-After is sent with every status that isn't 200
+func newStatusServer(t *testing.T, retryAfter string, statuses ...int) (*httptest.Server, *atomic.Int32) {
+	t.Helper()
+
+	requests := &atomic.Int32{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := int(requests.Add(1))
+		if n > len(statuses) {
+			return
+		}
+		w.Header().Set("Retry-After", retryAfter)
+		w.WriteHeader(statuses[n-1])
+	}))
+	t.Cleanup(server.Close)
+
+	return server, requests
+}
+
+func get(url string) func() (*http.Response, error) {
+	return func() (*http.Response, error) {
+		return http.Get(url)
+	}
+}
+
+func TestLimiterRetryAfter(t *testing.T) {
+	server, requests := newStatusServer(t, "1", http.StatusTooManyRequests)
+	limiter := NewLimiter(1000, 1)
+
+	start := time.Now()
+	response, err := limiter.Do(get(server.URL))
+	if err != nil {
+		t.Fatal(err)
+	}
+	response.Body.Close()
+
+	if response.StatusCode != http.StatusOK || requests.Load() != 2 {
+		t.Errorf("got %d after %d requests, want 200 after 2", response.StatusCode, requests.Load())
+	}
+	if elapsed := time.Since(start); elapsed < time.Second {
+		t.Errorf("retried after %v, want the 1s Retry-After", elapsed)
+	}
+}
+
+func TestLimiterRetryAfterDate(t *testing.T) {
+	// the date is only to the second, so the wait is between one and two seconds
+	server, requests := newStatusServer(t, time.Now().Add(2*time.Second).UTC().Format(http.TimeFormat), http.StatusServiceUnavailable)
+
+	start := time.Now()
+	response, err := NewLimiter(1000, 1).Do(get(server.URL))
+	if err != nil {
+		t.Fatal(err)
+	}
+	response.Body.Close()
+
+	if response.StatusCode != http.StatusOK || requests.Load() != 2 {
+		t.Errorf("got %d after %d requests, want 200 after 2", response.StatusCode, requests.Load())
+	}
+	if elapsed := time.Since(start); elapsed < 900*time.Millisecond || elapsed > 3*time.Second {
+		t.Errorf("retried after %v, want the Retry-After date", elapsed)
+	}
+
+	date := time.Now().Add(30 * time.Second).UTC().Format(http.TimeFormat)
+	// the date is only to the second, so it can be up to a second sooner
+	if d := backoff(0, date); d <= 28*time.Second || d > 30*time.Second {
+		t.Errorf("Retry-After %s waits %v, want about 30s", date, d)
+	}
+	if d := backoff(0, "7"); d != 7*time.Second {
+		t.Errorf("Retry-After 7 waits %v, want 7s", d)
+	}
+}
+
+func TestLimiterBackoff(t *testing.T) {
+	for attempt := 0; attempt <= 10; attempt++ {
+		d := time.Second << attempt
+		if d > maxBackoff {
+			d = maxBackoff
+		}
+		for i := 0; i < 100; i++ {
+			// the jitter keeps the wait between half and all of the doubled delay
+			if wait := backoff(attempt, ""); wait < d/2 || wait > d {
+				t.Fatalf("attempt %d waits %v, want between %v and %v", attempt, wait, d/2, d)
+			}
+		}
+	}
+}
+
+func TestLimiterGivesUp(t *testing.T) {
+	statuses := []int{}
+	for i := 0; i <= maxRetries+1; i++ {
+		statuses = append(statuses, http.StatusTooManyRequests)
+	}
+	server, requests := newStatusServer(t, "0", statuses...)
+
+	response, err := NewLimiter(1000, 1).Do(get(server.URL))
+	if err != nil {
+		t.Fatal(err)
+	}
+	response.Body.Close()
+
+	// the first try and every retry, then the last 429 is handed back
+	if response.StatusCode != http.StatusTooManyRequests || requests.Load() != maxRetries+1 {
+		t.Errorf("got %d after %d requests, want 429 after %d", response.StatusCode, requests.Load(), maxRetries+1)
+	}
+}
+
+func TestLimiterOnlyRetriesRateLimits(t *testing.T) {
+	server, requests := newStatusServer(t, "0", http.StatusServiceUnavailable, http.StatusInternalServerError)
+
+	response, err := NewLimiter(1000, 1).Do(get(server.URL))
+	if err != nil {
+		t.Fatal(err)
+	}
+	response.Body.Close()
+
+	if response.StatusCode != http.StatusInternalServerError || requests.Load() != 2 {
+		t.Errorf("got %d after %d requests, want the 500 after 2", response.StatusCode, requests.Load())
+	}
+}
+
+func TestLimiterForEach(t *testing.T) {
+	const workers = 3
+	limiter := NewLimiter(1000, workers)
+
+	var running, most atomic.Int32
+	seen := make([]int, 50)
+	mu := sync.Mutex{}
+
+	limiter.ForEach(len(seen), func(i int) {
+		now := running.Add(1)
+		for {
+			peak := most.Load()
+			if now <= peak || most.CompareAndSwap(peak, now) {
+				break
+			}
+		}
+		time.Sleep(time.Millisecond)
+		running.Add(-1)
+
+		mu.Lock()
+		seen[i]++
+		mu.Unlock()
+	})
+
+	if most.Load() > workers {
+		t.Errorf("%d ran at once, want at most %d", most.Load(), workers)
+	}
+	for i, count := range seen {
+		if count != 1 {
+			t.Errorf("index %d ran %d times, want once", i, count)
+		}
+	}
+}
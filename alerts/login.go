@@ -0,0 +1,228 @@
+package alerts
+
+import (
+	"net"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// LoginPage is a login prompt found in the http data of a service
+type LoginPage struct {
+	// Product is what the login is for, like "Fortinet FortiGate SSL VPN", or "Login page" when unknown
+	Product string
+	// Signal is the evidence the login was found by
+	Signal string
+	Scheme string
+	Path   string
+}
+
+// a known product with a login portal, any one of its patterns identifies it. Each pattern
+// is only checked against the part of the response it describes, so a page that merely
+// mentions a product doesn't count as its portal
+type loginFingerprint struct {
+	product string
+	// titles match the page title, headers match a whole response header line
+	titles  []*regexp.Regexp
+	paths   []*regexp.Regexp
+	headers []*regexp.Regexp
+}
+
+func patterns(exprs ...string) []*regexp.Regexp {
+	compiled := []*regexp.Regexp{}
+	for _, expr := range exprs {
+		compiled = append(compiled, regexp.MustCompile("(?i)"+expr))
+	}
+	return compiled
+}
+
+// checked in order, the first product with a matching pattern names the login
+var loginFingerprints = []loginFingerprint{
+	{product: "Microsoft Outlook Web Access", paths: patterns(`^/owa/auth`), titles: patterns(`^\s*Outlook( Web App)?\s*$`), headers: patterns(`^X-OWA-Version:`)},
+	{product: "Microsoft Remote Desktop Web Access", paths: patterns(`^/RDWeb`), titles: patterns(`RD Web Access`)},
+	{product: "Microsoft AD FS", paths: patterns(`^/adfs/ls`)},
+	{product: "Fortinet FortiGate SSL VPN", paths: patterns(`^/remote/login`), titles: patterns(`FortiGate`)},
+	{product: "Palo Alto GlobalProtect", paths: patterns(`^/global-protect/`), titles: patterns(`GlobalProtect Portal`)},
+	{product: "Ivanti Connect Secure", paths: patterns(`^/dana-na/`), titles: patterns(`Pulse Connect Secure`, `Ivanti Connect Secure`)},
+	{product: "Cisco ASA AnyConnect", paths: patterns(`^/\+CSCOE\+/`), headers: patterns(`^Set-Cookie:\s*webvpn`)},
+	{product: "Citrix Gateway", paths: patterns(`^/vpn/index\.html`, `^/logon/LogonPoint`), titles: patterns(`Citrix Gateway`, `NetScaler Gateway`)},
+	{product: "SonicWall SSL VPN", paths: patterns(`^/cgi-bin/welcome`), titles: patterns(`SonicWall`), headers: patterns(`^Server:\s*SonicWALL`)},
+	{product: "VMware Horizon", paths: patterns(`^/portal/webclient`), titles: patterns(`VMware Horizon`)},
+	{product: "Microsoft Exchange Control Panel", paths: patterns(`^/ecp/`)},
+	{product: "WordPress", paths: patterns(`(^|/)wp-login\.php$`)},
+	{product: "phpMyAdmin", titles: patterns(`phpMyAdmin`)},
+	{product: "cPanel", titles: patterns(`cPanel Login`, `WHM Login`)},
+	{product: "Grafana", titles: patterns(`^\s*Grafana\s*$`)},
+	{product: "Jenkins", titles: patterns(`Sign in \[Jenkins\]`)},
+}
+
+var (
+	wwwAuthenticatePattern = regexp.MustCompile(`(?im)^WWW-Authenticate:\s*(\w+)`)
+	passwordInputPattern   = regexp.MustCompile(`(?is)<form.*?<input[^>]*type\s*=\s*["']?password`)
+	loginTitlePattern      = regexp.MustCompile(`(?i)\b(log ?in|sign ?in|log ?on|sign ?on|authenticat)`)
+	// links, form actions and scripts the page points at
+	htmlUrlPattern = regexp.MustCompile(`(?i)\b(?:href|action|src)\s*=\s*["']?([^"'\s>]+)`)
+)
+
+// the paths the response points at, the redirect first and then the links in the page
+func responsePaths(location string, html string) []string {
+	urls := []string{location}
+	for _, match := range htmlUrlPattern.FindAllStringSubmatch(html, -1) {
+		urls = append(urls, match[1])
+	}
+
+	paths := []string{}
+	for _, raw := range urls {
+		parsed, err := url.Parse(strings.TrimSpace(raw))
+		if err != nil || parsed.Path == "" {
+			continue
+		}
+		paths = append(paths, parsed.Path)
+	}
+	return paths
+}
+
+// the path of the redirect, a location on another host or with a query keeps only its
+// path since the login is reported on the ip. "/" when there is none
+func locationPath(location string) string {
+	parsed, err := url.Parse(strings.TrimSpace(location))
+	if err != nil || parsed.Path == "" {
+		return "/"
+	}
+	return parsed.Path
+}
+
+// the header lines of the raw response, the status line and body are left off
+func responseHeaders(data string) []string {
+	head, _, _ := strings.Cut(strings.ReplaceAll(data, "\r\n", "\n"), "\n\n")
+	lines := strings.Split(head, "\n")
+	if len(lines) > 0 {
+		lines = lines[1:]
+	}
+	return lines
+}
+
+// finds a login page in the http data of a banner, nil when it has none
+func findLoginPage(banner ServiceBanner) *LoginPage {
+	if banner.Http == nil {
+		return nil
+	}
+	http := banner.Http
+
+	login := &LoginPage{
+		Scheme: "http",
+		Path:   locationPath(http.Location),
+	}
+	if banner.Ssl != nil {
+		login.Scheme = "https"
+	}
+
+	// known products first since they name what is exposed
+	paths := responsePaths(http.Location, http.Html)
+	headers := responseHeaders(banner.Data)
+	for _, fingerprint := range loginFingerprints {
+		for _, pattern := range fingerprint.titles {
+			if match := pattern.FindString(http.Title); match != "" {
+				login.Product = fingerprint.product
+				login.Signal = "title matched " + strconv.Quote(match)
+				return login
+			}
+		}
+		for _, pattern := range fingerprint.paths {
+			for _, path := range paths {
+				if pattern.MatchString(path) {
+					login.Product = fingerprint.product
+					login.Signal = "path " + strconv.Quote(path)
+					login.Path = path
+					return login
+				}
+			}
+		}
+		for _, pattern := range fingerprint.headers {
+			for _, header := range headers {
+				if pattern.MatchString(header) {
+					login.Product = fingerprint.product
+					login.Signal = "header " + strconv.Quote(header)
+					return login
+				}
+			}
+		}
+	}
+
+	if match := wwwAuthenticatePattern.FindStringSubmatch(banner.Data); match != nil {
+		login.Product = "HTTP " + match[1] + " authentication"
+		login.Signal = "WWW-Authenticate header"
+		return login
+	}
+
+	if passwordInputPattern.MatchString(http.Html) {
+		login.Product = "Login page"
+		login.Signal = "password form"
+		return login
+	}
+
+	if loginTitlePattern.MatchString(http.Title) {
+		login.Product = "Login page"
+		login.Signal = "title " + strconv.Quote(http.Title)
+		return login
+	}
+
+	return nil
+}
+
+// the address of the login page on a host, leaving off the default port for the scheme
+func (l LoginPage) Url(ip string, port int) string {
+	host := ip
+	if strings.Contains(ip, ":") {
+		host = "[" + ip + "]"
+	}
+	if !(l.Scheme == "http" && port == 80) && !(l.Scheme == "https" && port == 443) {
+		host = net.JoinHostPort(ip, strconv.Itoa(port))
+	}
+
+	path := l.Path
+	if !strings.HasPrefix(path, "/") {
+		path = "/" + path
+	}
+
+	return l.Scheme + "://" + host + path
+}
+
+// the login page on a port, nil when there is none
+func (e *Event) Login(port int) *LoginPage {
+	service, ok := e.Services()[port]
+	if !ok {
+		return nil
+	}
+	return service.Login
+}
+
+// LoginEndpoint is a login page on a host, for listing in reports
+type LoginEndpoint struct {
+	Ip      string
+	Port    int
+	Url     string
+	Product string
+}
+
+// LoginEndpoints lists every login page of the events, ordered by ip and then port
+func LoginEndpoints(events []*Event) []LoginEndpoint {
+	endpoints := []LoginEndpoint{}
+
+	for _, e := range events {
+		ports := e.Services()
+		for _, port := range sortedPorts(ports) {
+			if login := ports[port].Login; login != nil {
+				endpoints = append(endpoints, LoginEndpoint{
+					Ip:      e.Ip,
+					Port:    port,
+					Url:     login.Url(e.Ip, port),
+					Product: login.Product,
+				})
+			}
+		}
+	}
+
+	return endpoints
+}
@@ -0,0 +1,108 @@
+package alerts
+
+import (
+	"testing"
+)
+
+func httpBanner(title string, location string, html string, data string) ServiceBanner {
+	banner := ServiceBanner{Data: data}
+	banner.Http = &struct {
+		Title    string `json:"title,omitempty"`
+		Server   string `json:"server,omitempty"`
+		Status   int    `json:"status,omitempty"`
+		Location string `json:"location,omitempty"`
+		Html     string `json:"html,omitempty"`
+	}{Title: title, Location: location, Html: html}
+	return banner
+}
+
+func TestFindLoginPage(t *testing.T) {
+	tests := []struct {
+		name    string
+		banner  ServiceBanner
+		product string
+		path    string
+	}{
+		{"fortigate title", httpBanner("FortiGate SSL VPN", "", "", "HTTP/1.1 200 OK"), "Fortinet FortiGate SSL VPN", ""},
+		{"fortigate redirect", httpBanner("", "/remote/login?lang=en", "", "HTTP/1.1 302 Found"), "Fortinet FortiGate SSL VPN", "/remote/login"},
+		{"fortigate form action", httpBanner("", "", `<form action="/remote/logincheck">`, "HTTP/1.1 200 OK"), "Fortinet FortiGate SSL VPN", "/remote/logincheck"},
+		// a page about a product is not its portal
+		{"fortigate blog post", httpBanner("Blog", "", "<p>Patch your FortiGate, see fgt_lang and /remote/login</p>", "HTTP/1.1 200 OK"), "", ""},
+		{"anyconnect path", httpBanner("", "/+CSCOE+/logon.html", "", "HTTP/1.1 302 Found"), "Cisco ASA AnyConnect", "/+CSCOE+/logon.html"},
+		{"anyconnect cookie", httpBanner("", "", "", "HTTP/1.1 200 OK\r\nSet-Cookie: webvpncontext=00@ASA; path=/\r\n\r\n"), "Cisco ASA AnyConnect", ""},
+		{"webvpn in a page", httpBanner("Docs", "", "<p>configure webvpn on the firewall</p>", "HTTP/1.1 200 OK\r\nX-Note: webvpn\r\n\r\n"), "", ""},
+		{"ecp redirect", httpBanner("", "https://mail.example.com/ecp/?ReturnUrl=%2f", "", "HTTP/1.1 302 Found"), "Microsoft Exchange Control Panel", "/ecp/"},
+		{"ecp further down a path", httpBanner("", "/docs/ecp/setup", "", "HTTP/1.1 302 Found"), "", ""},
+		{"owa header", httpBanner("", "", "", "HTTP/1.1 200 OK\r\nX-OWA-Version: 15.1.2507\r\n\r\n"), "Microsoft Outlook Web Access", ""},
+		{"wordpress", httpBanner("My Site", "", `<a href="https://example.com/blog/wp-login.php">Log in</a>`, "HTTP/1.1 200 OK"), "WordPress", "/blog/wp-login.php"},
+		{"basic auth", httpBanner("", "", "", "HTTP/1.1 401 Unauthorized\r\nWWW-Authenticate: Basic realm=\"router\"\r\n\r\n"), "HTTP Basic authentication", ""},
+		{"password form", httpBanner("Home", "", `<form method="post"><input type="password" name="p"></form>`, "HTTP/1.1 200 OK"), "Login page", ""},
+		{"login title", httpBanner("Sign In", "", "", "HTTP/1.1 200 OK"), "Login page", ""},
+		{"nothing", httpBanner("Welcome to nginx!", "", "", "HTTP/1.1 200 OK"), "", ""},
+	}
+
+	for _, test := range tests {
+		login := findLoginPage(test.banner)
+		if test.product == "" {
+			if login != nil {
+				t.Errorf("%s: found %s by %s, want no login page", test.name, login.Product, login.Signal)
+			}
+			continue
+		}
+		if login == nil {
+			t.Errorf("%s: found no login page, want %s", test.name, test.product)
+			continue
+		}
+		if login.Product != test.product {
+			t.Errorf("%s: found %s by %s, want %s", test.name, login.Product, login.Signal, test.product)
+		}
+		if test.path != "" && login.Path != test.path {
+			t.Errorf("%s: login path is %q, want %q", test.name, login.Path, test.path)
+		}
+	}
+}
+
+func TestLoginPageUrl(t *testing.T) {
+	tests := []struct {
+		name   string
+		banner ServiceBanner
+		https  bool
+		ip     string
+		port   int
+		want   string
+	}{
+		// the redirect is to a hostname, the login is reported on the ip
+		{"absolute location", httpBanner("Outlook", "https://mail.example.com/owa/?x=1", "", "HTTP/1.1 302 Found"), true, "192.0.2.1", 443, "https://192.0.2.1/owa/"},
+		{"location with a query", httpBanner("", "/admin?next=%2F", "", "HTTP/1.1 401 Unauthorized\r\nWWW-Authenticate: Basic\r\n\r\n"), false, "192.0.2.1", 80, "http://192.0.2.1/admin"},
+		{"relative location with a query", httpBanner("Sign In", "/admin/login?next=%2F", "", "HTTP/1.1 302 Found"), false, "192.0.2.1", 8080, "http://192.0.2.1:8080/admin/login"},
+		{"query only location", httpBanner("Sign In", "?lang=en", "", "HTTP/1.1 302 Found"), true, "2001:db8::1", 443, "https://[2001:db8::1]/"},
+		{"no location", httpBanner("Sign In", "", "", "HTTP/1.1 200 OK"), true, "2001:db8::1", 8443, "https://[2001:db8::1]:8443/"},
+	}
+
+	for _, test := range tests {
+		if test.https {
+			test.banner.Ssl = &struct {
+				Cert struct {
+					Subject struct {
+						Cn string `json:"CN,omitempty"`
+					} `json:"subject"`
+					Issuer struct {
+						Cn string `json:"CN,omitempty"`
+					} `json:"issuer"`
+					Expires string `json:"expires,omitempty"`
+					Expired bool   `json:"expired,omitempty"`
+				} `json:"cert"`
+				Versions []string `json:"versions,omitempty"`
+			}{}
+		}
+
+		login := findLoginPage(test.banner)
+		if login == nil {
+			t.Errorf("%s: found no login page", test.name)
+			continue
+		}
+		if got := login.Url(test.ip, test.port); got != test.want {
+			t.Errorf("%s: url is %s, want %s", test.name, got, test.want)
+		}
+	}
+}
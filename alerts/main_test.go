@@ -0,0 +1,62 @@
+package alerts
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// runs every test in a scratch directory with its own resources, holding copies of the
+// datasets checked into the repo, so the caches and catalogs never touch the real ones
+func TestMain(m *testing.M) {
+	dir, err := os.MkdirTemp("", "alerts-test")
+	if err != nil {
+		panic(err)
+	}
+
+	resources := filepath.Join(dir, "resources")
+	os.MkdirAll(resources, 0755)
+	for _, name := range []string{"eol.json", "risky_ports.json", "priority_ssvc.json"} {
+		body, err := os.ReadFile(filepath.Join("..", "resources", name))
+		if err != nil {
+			panic(err)
+		}
+		os.WriteFile(filepath.Join(resources, name), body, 0644)
+	}
+
+	wd, _ := os.Getwd()
+	os.Chdir(dir)
+	// the stand-in servers answer at once, so nothing needs spacing out
+	os.Setenv("SHODAN_RPS", "1000")
+
+	code := m.Run()
+
+	os.Chdir(wd)
+	os.RemoveAll(dir)
+	os.Exit(code)
+}
+
+// a stand-in for the shodan api and monitor, answering each path with its handler
+func newShodanServer(t *testing.T, routes map[string]http.HandlerFunc) *HttpClient {
+	t.Helper()
+
+	mux := http.NewServeMux()
+	for path, handler := range routes {
+		mux.HandleFunc(path, handler)
+	}
+	server := httptest.NewServer(mux)
+	t.Cleanup(server.Close)
+
+	return NewHttpClient(server.URL, server.URL, server.URL, "test-key")
+}
+
+// answers with the body as json
+func jsonBody(body string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprint(w, body)
+	}
+}
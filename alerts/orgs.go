@@ -0,0 +1,103 @@
+package alerts
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+
+	_ "modernc.org/sqlite"
+)
+
+// Org is a monitored organization, the ranges are kept locally so the report
+// forms can be filled in without asking shodan
+type Org struct {
+	Name    string
+	AlertId string
+	Ranges  []string
+}
+
+type OrgStore struct {
+	db *sql.DB
+}
+
+func NewOrgStore() *OrgStore {
+	db, err := sql.Open("sqlite", "./resources/orgs.db")
+	if err != nil {
+		panic("Missing Resoruces")
+	}
+
+	store := &OrgStore{
+		db: db,
+	}
+
+	store.ensureTable()
+	return store
+}
+
+func (o *OrgStore) ensureTable() {
+	o.db.Exec(`CREATE TABLE IF NOT EXISTS orgs(
+alert_id TEXT PRIMARY KEY,
+name TEXT,
+ranges TEXT
+)`)
+}
+
+func (o *OrgStore) List() []Org {
+	rows, err := o.db.Query(`SELECT alert_id, name, ranges FROM orgs ORDER BY name`)
+	if err != nil {
+		fmt.Println("querying", err.Error())
+		return []Org{}
+	}
+	defer rows.Close()
+
+	orgs := []Org{}
+	for rows.Next() {
+		org := Org{}
+		var ranges string
+
+		if err := rows.Scan(&org.AlertId, &org.Name, &ranges); err != nil {
+			fmt.Println("scanning", err.Error())
+			continue
+		}
+
+		org.Ranges = strings.FieldsFunc(ranges, func(r rune) bool {
+			return r == ','
+		})
+		orgs = append(orgs, org)
+	}
+
+	return orgs
+}
+
+func (o *OrgStore) Save(org Org) {
+	_, err := o.db.Exec(`INSERT INTO orgs(alert_id, name, ranges) VALUES (?,?,?)
+ON CONFLICT(alert_id) DO UPDATE SET name = excluded.name, ranges = excluded.ranges`, org.AlertId, org.Name, strings.Join(org.Ranges, ","))
+	if err != nil {
+		fmt.Println("insert", err.Error())
+	}
+}
+
+func (o *OrgStore) Delete(alertId string) {
+	o.db.Exec(`DELETE FROM orgs WHERE alert_id = ?`, alertId)
+}
+
+// Sync makes the local orgs match the network alerts on the account
+func (o *OrgStore) Sync(index *AlertIndex) {
+	listed := make(map[string]bool)
+
+	for _, alert := range index.Alerts {
+		listed[alert.Id] = true
+		o.Save(Org{Name: alert.Name, AlertId: alert.Id, Ranges: alert.Filters.Ip})
+	}
+
+	for _, org := range o.List() {
+		if !listed[org.AlertId] {
+			o.Delete(org.AlertId)
+		}
+	}
+}
+
+// the ranges as they are typed into the report forms
+func (o Org) RangeString() string {
+	return strings.Join(o.Ranges, ", ")
+}
@@ -0,0 +1,90 @@
+package alerts
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+)
+
+const riskyPortsPath = "./resources/risky_ports.json"
+
+// PortRisk is an entry of the risky port catalog. An entry matches a service by port,
+// transport and a case insensitive substring of the product, any of which can be left empty
+type PortRisk struct {
+	Port        int    `json:"port,omitempty"`
+	Transport   string `json:"transport,omitempty"`
+	Product     string `json:"product,omitempty"`
+	Service     string `json:"service"`
+	Risk        string `json:"risk"`
+	Technique   string `json:"technique"`
+	Remediation string `json:"remediation"`
+}
+
+var (
+	riskyPorts     []PortRisk
+	riskyPortsOnce sync.Once
+)
+
+// RiskyPorts is the catalog in resources/risky_ports.json, read on first use
+func RiskyPorts() []PortRisk {
+	riskyPortsOnce.Do(func() {
+		body, err := os.ReadFile(riskyPortsPath)
+		if err != nil {
+			fmt.Println("Error: reading risky port catalog", err.Error())
+			return
+		}
+
+		if err := json.Unmarshal(body, &riskyPorts); err != nil {
+			fmt.Println("Error: parsing risky port catalog", err.Error())
+		}
+	})
+	return riskyPorts
+}
+
+// how closely the entry describes the service, -1 when it doesn't match at all
+func (r PortRisk) score(port int, service Service) int {
+	if r.Port == 0 && r.Product == "" {
+		return -1
+	}
+
+	score := 0
+	if r.Port != 0 {
+		if r.Port != port {
+			return -1
+		}
+		score += 2
+	}
+	// a port nothing is known about could be either transport
+	if r.Transport != "" && service.Transport != "" {
+		if r.Transport != service.Transport {
+			return -1
+		}
+		score += 1
+	}
+	if r.Product != "" {
+		if !strings.Contains(strings.ToLower(service.Product), strings.ToLower(r.Product)) {
+			return -1
+		}
+		score += 4
+	}
+
+	return score
+}
+
+// the catalog entry that best describes the port, nil when the port isn't in the catalog
+func (e *Event) PortRisk(port int) *PortRisk {
+	service := e.Services()[port]
+
+	var best *PortRisk
+	bestScore := -1
+	for i, risk := range RiskyPorts() {
+		if score := risk.score(port, service); score > bestScore {
+			best = &riskyPorts[i]
+			bestScore = score
+		}
+	}
+
+	return best
+}
@@ -0,0 +1,44 @@
+package alerts
+
+import "testing"
+
+// matched against resources/risky_ports.json, copied in by TestMain
+func TestPortRisk(t *testing.T) {
+	tests := []struct {
+		name    string
+		port    int
+		service Service
+		want    string
+	}{
+		{"rdp", 3389, Service{Transport: "tcp"}, "RDP"},
+		{"ssh", 22, Service{Transport: "tcp", Product: "OpenSSH"}, "SSH"},
+		// the product says what answered, wherever it was moved to
+		{"product on another port", 8443, Service{Transport: "tcp", Product: "Remote Desktop Protocol"}, "RDP"},
+		{"product over port", 3389, Service{Transport: "tcp", Product: "VNC"}, "VNC"},
+		{"product in lower case", 6380, Service{Transport: "tcp", Product: "redis key-value store"}, "Redis"},
+		{"snmp", 161, Service{Transport: "udp"}, "SNMP"},
+		{"snmp over tcp", 161, Service{Transport: "tcp"}, ""},
+		{"dns over tcp", 53, Service{Transport: "tcp"}, ""},
+		// a transport other than tcp or udp matches no entry that names one
+		{"rdp over sctp", 3389, Service{Transport: "sctp"}, ""},
+		// a port nothing is known about could be either transport
+		{"snmp without a banner", 161, Service{}, "SNMP"},
+		{"not in the catalog", 8080, Service{Transport: "tcp", Product: "nginx"}, ""},
+	}
+
+	for _, test := range tests {
+		e := NewEventFromIp("192.0.2.1")
+		e.services[test.port] = test.service
+
+		risk := e.PortRisk(test.port)
+		if test.want == "" {
+			if risk != nil {
+				t.Errorf("%s: matched %s, want no match", test.name, risk.Service)
+			}
+			continue
+		}
+		if risk == nil || risk.Service != test.want {
+			t.Errorf("%s: matched %v, want %s", test.name, risk, test.want)
+		}
+	}
+}
@@ -0,0 +1,359 @@
+package alerts
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+)
+
+const (
+	ThresholdPolicy = "threshold"
+	SsvcPolicy      = "ssvc"
+)
+
+// PriorityPolicy decides the priority of every cve. A threshold policy gives each cve the
+// first level whose conditions it meets, a ssvc policy walks the decision tree in Ssvc
+// and gives it the level named by the matching rule. Levels are listed highest priority first
+type PriorityPolicy struct {
+	Type       string            `json:"type"`
+	References []PolicyReference `json:"references"`
+	Levels     []PriorityLevel   `json:"levels"`
+	Ssvc       *SsvcTree         `json:"ssvc,omitempty"`
+}
+
+type PolicyReference struct {
+	Title string `json:"title"`
+	Url   string `json:"url"`
+}
+
+type PriorityLevel struct {
+	Label       string `json:"label"`
+	Severity    string `json:"severity"`
+	Description string `json:"description"`
+	// highlighted levels are expanded upon in the impact sections of reports
+	Highlight bool `json:"highlight"`
+
+	// conditions for threshold policies, a level with none set matches every cve
+	Kev     *bool    `json:"kev,omitempty"`
+	CvssMin *float32 `json:"cvss_min,omitempty"`
+	EpssMin *float32 `json:"epss_min,omitempty"`
+}
+
+// SsvcTree is a SSVC style decision tree over exploitation, automatable and technical impact
+type SsvcTree struct {
+	// an epss score at or above PocEpss counts as a public proof of concept
+	PocEpss float32 `json:"poc_epss"`
+	// a cvss score at or above TotalImpactCvss counts as total technical impact
+	TotalImpactCvss float32    `json:"total_impact_cvss"`
+	Rules           []SsvcRule `json:"rules"`
+}
+
+// SsvcRule gives Decision, the label of a level, to the cves matching every value set.
+// Exploitation is none, poc or active, Automatable is yes or no and TechnicalImpact is
+// partial or total, an empty value matches anything
+type SsvcRule struct {
+	Exploitation    string `json:"exploitation"`
+	Automatable     string `json:"automatable"`
+	TechnicalImpact string `json:"technical_impact"`
+	Decision        string `json:"decision"`
+}
+
+func boolPtr(b bool) *bool        { return &b }
+func floatPtr(f float32) *float32 { return &f }
+
+// the policy used when PRIORITY_POLICY is unset
+func DefaultPriorityPolicy() PriorityPolicy {
+	return PriorityPolicy{
+		Type: ThresholdPolicy,
+		References: []PolicyReference{
+			{Title: "EPSS User Guide (first.org)", Url: "https://www.first.org/epss/user-guide"},
+			{Title: "CVSS, EPSS, and CISA's Known Exploited Vulnerabilities (Github.com)", Url: "https://github.com/eagledb14/CVE_Prioritizer/tree/main?tab=readme-ov-file#our-approach"},
+		},
+		Levels: []PriorityLevel{
+			{
+				Label:       "Priority 0",
+				Severity:    "HIGH",
+				Description: "CISA (Cybersecurity and Infrastructure Security Agency) has declared this vulnerability as being a known exploited vulnerability. Should be taken as highest priority and addressed immediately.",
+				Highlight:   true,
+				Kev:         boolPtr(true),
+			},
+			{
+				Label:       "Priority 1",
+				Severity:    "HIGH",
+				Description: "The most critical kinds of vulnerabilities which are more likely to be exploited, and could fully compromise the information system. They should be patched first.",
+				Highlight:   true,
+				CvssMin:     floatPtr(6.0),
+				EpssMin:     floatPtr(0.2),
+			},
+			{
+				Label:       "Priority 2",
+				Severity:    "MODERATE",
+				Description: "May severely impact the system, are much less likely to be exploited, relative to others, but should still be watched in the event that the threat landscape changes.",
+				CvssMin:     floatPtr(6.0),
+			},
+			{
+				Label:       "Priority 3",
+				Severity:    "MODERATE",
+				Description: "May be more likely to be exploited, but, on their own, would not critically impact the information system.",
+				EpssMin:     floatPtr(0.2),
+			},
+			{
+				Label:       "Priority 4",
+				Severity:    "LOW",
+				Description: "Vulnerabilities may be more likely to be exploited on their own, but would not critically impact the system.",
+			},
+		},
+	}
+}
+
+// LoadPriorityPolicy reads a policy from a json file
+func LoadPriorityPolicy(path string) (PriorityPolicy, error) {
+	policy := PriorityPolicy{}
+
+	body, err := os.ReadFile(path)
+	if err != nil {
+		return policy, err
+	}
+
+	if err := json.Unmarshal(body, &policy); err != nil {
+		return policy, fmt.Errorf("%s: %w", path, err)
+	}
+
+	if err := policy.validate(); err != nil {
+		return policy, fmt.Errorf("%s: %w", path, err)
+	}
+
+	return policy, nil
+}
+
+func (p *PriorityPolicy) validate() error {
+	if p.Type == "" {
+		p.Type = ThresholdPolicy
+	}
+	if len(p.Levels) == 0 {
+		return errors.New("the policy has no levels")
+	}
+
+	labels := make(map[string]bool)
+	for _, level := range p.Levels {
+		if level.Label == "" {
+			return errors.New("every level needs a label")
+		}
+		if labels[level.Label] {
+			return fmt.Errorf("level %q is listed twice", level.Label)
+		}
+		labels[level.Label] = true
+	}
+
+	switch p.Type {
+	case ThresholdPolicy:
+		return nil
+	case SsvcPolicy:
+		if p.Ssvc == nil || len(p.Ssvc.Rules) == 0 {
+			return errors.New("a ssvc policy needs decision tree rules")
+		}
+		// left out they read as 0, which would count every cve as a proof of concept with total impact
+		if p.Ssvc.PocEpss <= 0 || p.Ssvc.PocEpss > 1 {
+			return fmt.Errorf("poc_epss must be above 0 and at most 1, not %g", p.Ssvc.PocEpss)
+		}
+		if p.Ssvc.TotalImpactCvss <= 0 {
+			return fmt.Errorf("total_impact_cvss must be above 0, not %g", p.Ssvc.TotalImpactCvss)
+		}
+		for _, rule := range p.Ssvc.Rules {
+			if !labels[rule.Decision] {
+				return fmt.Errorf("rule decision %q is not a level", rule.Decision)
+			}
+			if !oneOf(rule.Exploitation, "", "none", "poc", "active") {
+				return fmt.Errorf("unknown exploitation %q", rule.Exploitation)
+			}
+			if !oneOf(rule.Automatable, "", "yes", "no") {
+				return fmt.Errorf("unknown automatable %q", rule.Automatable)
+			}
+			if !oneOf(rule.TechnicalImpact, "", "partial", "total") {
+				return fmt.Errorf("unknown technical impact %q", rule.TechnicalImpact)
+			}
+		}
+		return nil
+	default:
+		return fmt.Errorf("unknown policy type %q", p.Type)
+	}
+}
+
+func oneOf(value string, options ...string) bool {
+	for _, option := range options {
+		if value == option {
+			return true
+		}
+	}
+	return false
+}
+
+var (
+	activePolicy     PriorityPolicy
+	activePolicyOnce sync.Once
+)
+
+// ActivePolicy is the policy named by PRIORITY_POLICY, or the default policy when unset.
+// It is read on first use so the env file has been loaded by then
+func ActivePolicy() PriorityPolicy {
+	activePolicyOnce.Do(func() {
+		path := os.Getenv("PRIORITY_POLICY")
+		if path == "" {
+			activePolicy = DefaultPriorityPolicy()
+			return
+		}
+
+		policy, err := LoadPriorityPolicy(path)
+		if err != nil {
+			panic("error loading priority policy: " + err.Error())
+		}
+		activePolicy = policy
+	})
+	return activePolicy
+}
+
+// Rank returns the index of the level the cve falls in, cves matching no level get the last one
+func (p PriorityPolicy) Rank(c Cve) int {
+	if p.Type == SsvcPolicy {
+		return p.rankSsvc(c)
+	}
+
+	for i, level := range p.Levels {
+		if level.matches(c) {
+			return i
+		}
+	}
+	return len(p.Levels) - 1
+}
+
+func (l PriorityLevel) matches(c Cve) bool {
+	if l.Kev != nil && *l.Kev != c.Kev {
+		return false
+	}
+	if l.CvssMin != nil && c.Cvss < *l.CvssMin {
+		return false
+	}
+	if l.EpssMin != nil && c.Epss < *l.EpssMin {
+		return false
+	}
+	return true
+}
+
+func (p PriorityPolicy) rankSsvc(c Cve) int {
+	exploitation, automatable, impact := p.Ssvc.inputs(c)
+
+	for _, rule := range p.Ssvc.Rules {
+		if (rule.Exploitation == "" || rule.Exploitation == exploitation) &&
+			(rule.Automatable == "" || rule.Automatable == automatable) &&
+			(rule.TechnicalImpact == "" || rule.TechnicalImpact == impact) {
+			return p.levelIndex(rule.Decision)
+		}
+	}
+	return len(p.Levels) - 1
+}
+
+// the decision point values for a cve
+func (s SsvcTree) inputs(c Cve) (string, string, string) {
+	exploitation := "none"
+	if c.Kev {
+		exploitation = "active"
+	} else if c.Epss >= s.PocEpss {
+		exploitation = "poc"
+	}
+
+	automatable := "no"
+	if automatableVector(c.Vector) {
+		automatable = "yes"
+	}
+
+	impact := "partial"
+	if c.Cvss >= s.TotalImpactCvss {
+		impact = "total"
+	}
+
+	return exploitation, automatable, impact
+}
+
+// a vulnerability reachable over the network with no privileges or user interaction can be
+// exploited at scale, cvss 2 vectors have no user interaction metric
+func automatableVector(vector string) bool {
+	metrics := make(map[string]string)
+	for _, metric := range strings.Split(vector, "/") {
+		key, value, _ := strings.Cut(metric, ":")
+		metrics[key] = value
+	}
+
+	if metrics["AV"] != "N" {
+		return false
+	}
+	if _, ok := metrics["Au"]; ok {
+		return metrics["Au"] == "N"
+	}
+	return metrics["PR"] == "N" && metrics["UI"] == "N"
+}
+
+func (p PriorityPolicy) levelIndex(label string) int {
+	for i, level := range p.Levels {
+		if level.Label == label {
+			return i
+		}
+	}
+	return len(p.Levels) - 1
+}
+
+// the labels of the highlighted levels
+func (p PriorityPolicy) HighlightLabels() []string {
+	labels := []string{}
+	for _, level := range p.Levels {
+		if level.Highlight {
+			labels = append(labels, level.Label)
+		}
+	}
+	return labels
+}
+
+func (p PriorityPolicy) Highlighted(rank int) bool {
+	return rank >= 0 && rank < len(p.Levels) && p.Levels[rank].Highlight
+}
+
+func (p PriorityPolicy) Highest() string {
+	return p.Levels[0].Label
+}
+
+func (p PriorityPolicy) Lowest() string {
+	return p.Levels[len(p.Levels)-1].Label
+}
+
+// Criteria describes the conditions of a threshold level, like "CVSS of at least 6.0 and EPSS of at least 0.2"
+func (l PriorityLevel) Criteria() string {
+	criteria := []string{}
+	if l.Kev != nil {
+		if *l.Kev {
+			criteria = append(criteria, "Listed in the CISA Known Exploited Vulnerabilities catalog")
+		} else {
+			criteria = append(criteria, "Not listed in the CISA Known Exploited Vulnerabilities catalog")
+		}
+	}
+	if l.CvssMin != nil {
+		criteria = append(criteria, fmt.Sprintf("CVSS of at least %.1f", *l.CvssMin))
+	}
+	if l.EpssMin != nil {
+		criteria = append(criteria, fmt.Sprintf("EPSS of at least %g", *l.EpssMin))
+	}
+
+	if len(criteria) == 0 {
+		return "Every remaining vulnerability"
+	}
+	return strings.Join(criteria, " and ")
+}
+
+// labels the cve with the level the active policy gives it
+func (c *Cve) rank() {
+	policy := ActivePolicy()
+	c.Rank = policy.Rank(*c)
+	c.Priority = policy.Levels[c.Rank].Label
+	c.Severity = policy.Levels[c.Rank].Severity
+}
@@ -0,0 +1,162 @@
+package alerts
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestLoadSsvcPolicy(t *testing.T) {
+	example, err := os.ReadFile(filepath.Join("resources", "priority_ssvc.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name    string
+		replace string
+		with    string
+		err     string
+	}{
+		{"the example", "", "", ""},
+		{"epss of 1", `"poc_epss": 0.2`, `"poc_epss": 1`, ""},
+		{"no epss", `"poc_epss": 0.2,`, ``, "poc_epss must be above 0"},
+		{"zero epss", `"poc_epss": 0.2`, `"poc_epss": 0`, "poc_epss must be above 0"},
+		{"epss as a percentage", `"poc_epss": 0.2`, `"poc_epss": 20`, "poc_epss must be above 0 and at most 1, not 20"},
+		{"no cvss", `"total_impact_cvss": 9.0,`, ``, "total_impact_cvss must be above 0"},
+		{"negative cvss", `"total_impact_cvss": 9.0`, `"total_impact_cvss": -1`, "total_impact_cvss must be above 0, not -1"},
+	}
+
+	for _, test := range tests {
+		body := string(example)
+		if test.replace != "" {
+			if !strings.Contains(body, test.replace) {
+				t.Fatalf("%s: the example has no %q", test.name, test.replace)
+			}
+			body = strings.Replace(body, test.replace, test.with, 1)
+		}
+
+		path := filepath.Join(t.TempDir(), "policy.json")
+		os.WriteFile(path, []byte(body), 0644)
+
+		_, err := LoadPriorityPolicy(path)
+		if test.err == "" && err != nil {
+			t.Errorf("%s: %v", test.name, err)
+		}
+		if test.err != "" && (err == nil || !strings.Contains(err.Error(), test.err)) {
+			t.Errorf("%s: got error %v, want %q", test.name, err, test.err)
+		}
+	}
+}
+
+// the ranking used before priority policies, the default policy has to give the same ranks
+func thresholdRank(cvss float32, epss float32, kev bool) (int, string) {
+	if kev {
+		return 0, "HIGH"
+	} else if cvss >= 6.0 {
+		if epss >= 0.2 {
+			return 1, "HIGH"
+		}
+		return 2, "MODERATE"
+	} else if epss >= 0.2 {
+		return 3, "MODERATE"
+	}
+	return 4, "LOW"
+}
+
+func TestDefaultPolicyRank(t *testing.T) {
+	policy := DefaultPriorityPolicy()
+
+	tests := []struct {
+		cve  Cve
+		want int
+	}{
+		{Cve{Cvss: 2.0, Epss: 0.01, Kev: true}, 0},
+		{Cve{Cvss: 6.0, Epss: 0.2}, 1},
+		{Cve{Cvss: 6.0, Epss: 0.19}, 2},
+		{Cve{Cvss: 5.9, Epss: 0.2}, 3},
+		{Cve{Cvss: 5.9, Epss: 0.19}, 4},
+		{Cve{}, 4},
+	}
+	for _, test := range tests {
+		if rank := policy.Rank(test.cve); rank != test.want {
+			t.Errorf("cvss %v epss %v kev %v ranked %d, want %d", test.cve.Cvss, test.cve.Epss, test.cve.Kev, rank, test.want)
+		}
+	}
+
+	// every score around the thresholds
+	for _, cvss := range []float32{0, 5.9, 5.99, 6.0, 6.01, 10} {
+		for _, epss := range []float32{0, 0.19, 0.199, 0.2, 0.201, 1} {
+			for _, kev := range []bool{false, true} {
+				want, severity := thresholdRank(cvss, epss, kev)
+				rank := policy.Rank(Cve{Cvss: cvss, Epss: epss, Kev: kev})
+				if rank != want || policy.Levels[rank].Severity != severity {
+					t.Errorf("cvss %v epss %v kev %v ranked %d %s, want %d %s", cvss, epss, kev,
+						rank, policy.Levels[rank].Severity, want, severity)
+				}
+			}
+		}
+	}
+}
+
+func TestAutomatableVector(t *testing.T) {
+	tests := []struct {
+		vector string
+		want   bool
+	}{
+		{"AV:N/AC:L/Au:N/C:P/I:P/A:P", true},
+		{"AV:N/AC:L/Au:S/C:P/I:P/A:P", false},
+		{"AV:L/AC:L/Au:N/C:C/I:C/A:C", false},
+		{"CVSS:3.1/AV:N/AC:L/PR:N/UI:N/S:U/C:H/I:H/A:H", true},
+		{"CVSS:3.1/AV:N/AC:L/PR:L/UI:N/S:U/C:H/I:H/A:H", false},
+		{"CVSS:3.1/AV:N/AC:L/PR:N/UI:R/S:U/C:H/I:H/A:H", false},
+		{"CVSS:3.1/AV:A/AC:L/PR:N/UI:N/S:U/C:H/I:H/A:H", false},
+		{"", false},
+	}
+
+	for _, test := range tests {
+		if automatable := automatableVector(test.vector); automatable != test.want {
+			t.Errorf("%q automatable %v, want %v", test.vector, automatable, test.want)
+		}
+	}
+}
+
+func TestSsvcRank(t *testing.T) {
+	policy, err := LoadPriorityPolicy(filepath.Join("resources", "priority_ssvc.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	const (
+		v2Automatable = "AV:N/AC:L/Au:N/C:P/I:P/A:P"
+		v2Local       = "AV:L/AC:L/Au:N/C:P/I:P/A:P"
+		v3Automatable = "CVSS:3.1/AV:N/AC:L/PR:N/UI:N/S:U/C:H/I:H/A:H"
+		v3Interaction = "CVSS:3.1/AV:N/AC:L/PR:N/UI:R/S:U/C:H/I:H/A:H"
+	)
+
+	tests := []struct {
+		name string
+		cve  Cve
+		want string
+	}{
+		{"active automatable v2", Cve{Kev: true, Cvss: 7.5, Vector: v2Automatable}, "Act"},
+		{"active automatable v3", Cve{Kev: true, Cvss: 7.5, Vector: v3Automatable}, "Act"},
+		{"active total", Cve{Kev: true, Cvss: 9.0, Vector: v3Interaction}, "Act"},
+		{"active", Cve{Kev: true, Cvss: 8.8, Vector: v3Interaction}, "Attend"},
+		{"poc automatable v2", Cve{Epss: 0.2, Cvss: 7.5, Vector: v2Automatable}, "Attend"},
+		{"poc automatable v3", Cve{Epss: 0.2, Cvss: 7.5, Vector: v3Automatable}, "Attend"},
+		{"poc total", Cve{Epss: 0.2, Cvss: 9.8, Vector: v2Local}, "Track*"},
+		{"poc", Cve{Epss: 0.2, Cvss: 7.5, Vector: v3Interaction}, "Track"},
+		{"automatable total", Cve{Epss: 0.01, Cvss: 9.8, Vector: v3Automatable}, "Attend"},
+		{"automatable v2", Cve{Epss: 0.01, Cvss: 7.5, Vector: v2Automatable}, "Track*"},
+		{"total", Cve{Epss: 0.01, Cvss: 9.8, Vector: v2Local}, "Track*"},
+		{"none", Cve{Epss: 0.01, Cvss: 7.5, Vector: v3Interaction}, "Track"},
+	}
+
+	for _, test := range tests {
+		if label := policy.Levels[policy.Rank(test.cve)].Label; label != test.want {
+			t.Errorf("%s: ranked %s, want %s", test.name, label, test.want)
+		}
+	}
+}
@@ -0,0 +1,248 @@
+package alerts
+
+import (
+	"errors"
+	"fmt"
+	"net/netip"
+	"regexp"
+	"strings"
+)
+
+// SearchFilter is a shodan filter the query builder takes values for
+type SearchFilter struct {
+	Name  string
+	Label string
+	// List filters take values separated by commas or spaces as well as lines,
+	// the others can hold commas and spaces so they take one value per line
+	List bool
+}
+
+// the filters offered on the search fields, net is filled from the address fields instead
+var SearchFilters = []SearchFilter{
+	{Name: "org", Label: "Organization"},
+	{Name: "hostname", Label: "Hostname", List: true},
+	{Name: "ssl.cert.subject.cn", Label: "SSL Certificate Common Name"},
+	{Name: "asn", Label: "ASN", List: true},
+}
+
+var asnPattern = regexp.MustCompile(`(?i)^(AS)?([0-9]{1,10})$`)
+
+// QueryFilter is one filter of a query, the host has to match one of its values,
+// or none of them when the filter is negated
+type QueryFilter struct {
+	Name    string
+	Values  []string
+	Negated bool
+}
+
+// Query is a shodan search built from filters, a host has to match every filter
+type Query struct {
+	Filters []QueryFilter
+}
+
+// Add checks and adds values for a filter, values already in the filter and empty values
+// are skipped. A name starting with "-" adds to the negated filter, like -net:10.0.0.0/24.
+// Nothing is added when any value is invalid
+func (q *Query) Add(name string, values ...string) error {
+	negated := strings.HasPrefix(name, "-")
+	name = strings.TrimPrefix(name, "-")
+
+	checked := []string{}
+	for _, value := range values {
+		value = strings.TrimSpace(value)
+		if value == "" {
+			continue
+		}
+
+		value, err := checkFilterValue(name, value)
+		if err != nil {
+			return err
+		}
+		checked = append(checked, value)
+	}
+	if len(checked) == 0 {
+		return nil
+	}
+
+	for i := range q.Filters {
+		if q.Filters[i].Name != name || q.Filters[i].Negated != negated {
+			continue
+		}
+		for _, value := range checked {
+			if !oneOf(value, q.Filters[i].Values...) {
+				q.Filters[i].Values = append(q.Filters[i].Values, value)
+			}
+		}
+		return nil
+	}
+
+	filter := QueryFilter{Name: name, Negated: negated}
+	for _, value := range checked {
+		if !oneOf(value, filter.Values...) {
+			filter.Values = append(filter.Values, value)
+		}
+	}
+	q.Filters = append(q.Filters, filter)
+	return nil
+}
+
+// puts a value in the form shodan expects for the filter
+func checkFilterValue(name string, value string) (string, error) {
+	switch name {
+	case "net":
+		if prefix, err := netip.ParsePrefix(value); err == nil {
+			return prefix.Masked().String(), nil
+		}
+		if addr, err := netip.ParseAddr(value); err == nil {
+			return addr.Unmap().String(), nil
+		}
+		return "", fmt.Errorf("net %q: not an ip or cidr", value)
+	case "asn":
+		match := asnPattern.FindStringSubmatch(value)
+		if match == nil {
+			return "", fmt.Errorf("asn %q: not a number like AS15169", value)
+		}
+		return "AS" + match[2], nil
+	case "hostname":
+		if !isHostname(value) {
+			return "", fmt.Errorf("hostname %q: not a valid hostname", value)
+		}
+		return strings.ToLower(strings.TrimSuffix(value, ".")), nil
+	case "org", "ssl.cert.subject.cn":
+		if strings.ContainsAny(value, "\r\n") {
+			return "", fmt.Errorf("%s %q: can't span lines", name, value)
+		}
+		return value, nil
+	}
+
+	return "", fmt.Errorf("unknown filter %q", name)
+}
+
+// Empty is true when the query has no filters
+func (q Query) Empty() bool {
+	return len(q.Filters) == 0
+}
+
+// the query as shodan search syntax, like org:"Acme, Inc." asn:AS15169.
+// An empty query gives an empty string
+func (q Query) String() string {
+	filters := []string{}
+	for _, filter := range q.Filters {
+		values := []string{}
+		for _, value := range filter.Values {
+			values = append(values, quoteFilterValue(value))
+		}
+		name := filter.Name
+		if filter.Negated {
+			name = "-" + name
+		}
+		filters = append(filters, name+":"+strings.Join(values, ","))
+	}
+	return strings.Join(filters, " ")
+}
+
+// values with spaces, commas, colons or quotes are quoted so they stay one value,
+// with backslashes and quotes inside escaped
+func quoteFilterValue(value string) string {
+	if !strings.ContainsAny(value, " \t,:\"'\\") {
+		return value
+	}
+
+	value = strings.ReplaceAll(value, `\`, `\\`)
+	value = strings.ReplaceAll(value, `"`, `\"`)
+	return `"` + value + `"`
+}
+
+// splits the text of a search field into values, one per line or for list filters
+// also separated by commas and spaces
+func (f SearchFilter) Values(text string) []string {
+	if f.List {
+		return strings.FieldsFunc(text, func(r rune) bool {
+			return r == ',' || r == ';' || r == ' ' || r == '\t' || r == '\n' || r == '\r'
+		})
+	}
+	return strings.FieldsFunc(text, func(r rune) bool {
+		return r == '\n' || r == '\r'
+	})
+}
+
+// AddFields adds the text of each search field, keyed by filter name. The valid values
+// are still added when some aren't, every invalid value is listed in the error
+func (q *Query) AddFields(fields map[string]string) error {
+	errs := []string{}
+
+	for _, filter := range SearchFilters {
+		for _, value := range filter.Values(fields[filter.Name]) {
+			if err := q.Add(filter.Name, value); err != nil {
+				errs = append(errs, err.Error())
+			}
+		}
+	}
+
+	if len(errs) > 0 {
+		return errors.New("invalid search filters " + strings.Join(errs, ", "))
+	}
+	return nil
+}
+
+// ParseQuery reads a query written by String back into its filters
+func ParseQuery(text string) (Query, error) {
+	query := Query{}
+
+	rest := strings.TrimSpace(text)
+	for rest != "" {
+		name, values, ok := strings.Cut(rest, ":")
+		if !ok || name == "" || strings.ContainsAny(name, " \t") {
+			return query, fmt.Errorf("%q is not a filter:value pair", rest)
+		}
+
+		filter := QueryFilter{Name: name}
+		for {
+			value, remaining, err := readFilterValue(values)
+			if err != nil {
+				return query, fmt.Errorf("%s: %w", name, err)
+			}
+			filter.Values = append(filter.Values, value)
+
+			if strings.HasPrefix(remaining, ",") {
+				values = remaining[1:]
+				continue
+			}
+			rest = strings.TrimSpace(remaining)
+			break
+		}
+
+		if err := query.Add(filter.Name, filter.Values...); err != nil {
+			return query, err
+		}
+	}
+
+	return query, nil
+}
+
+// reads one value off the front of text, quoted or ending at a comma or space
+func readFilterValue(text string) (string, string, error) {
+	if !strings.HasPrefix(text, `"`) {
+		end := strings.IndexAny(text, ", \t")
+		if end < 0 {
+			end = len(text)
+		}
+		return text[:end], text[end:], nil
+	}
+
+	value := strings.Builder{}
+	for i := 1; i < len(text); i++ {
+		switch text[i] {
+		case '\\':
+			if i+1 < len(text) {
+				i++
+				value.WriteByte(text[i])
+			}
+		case '"':
+			return value.String(), text[i+1:], nil
+		default:
+			value.WriteByte(text[i])
+		}
+	}
+	return "", "", errors.New("quote is never closed")
+}
@@ -0,0 +1,153 @@
+package alerts
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestQueryString(t *testing.T) {
+	tests := []struct {
+		name    string
+		filters []QueryFilter
+		want    string
+	}{
+		{"empty", nil, ""},
+		{"plain values", []QueryFilter{{"net", []string{"10.0.0.0/24", "10.0.1.1"}, false}, {"asn", []string{"AS15169"}, false}}, "net:10.0.0.0/24,10.0.1.1 asn:AS15169"},
+		{"spaces and commas", []QueryFilter{{"org", []string{"Acme, Inc."}, false}}, `org:"Acme, Inc."`},
+		{"colons", []QueryFilter{{"net", []string{"2001:db8::/32"}, false}}, `net:"2001:db8::/32"`},
+		{"quotes and backslashes", []QueryFilter{{"ssl.cert.subject.cn", []string{`say "hi" \ bye`}, false}}, `ssl.cert.subject.cn:"say \"hi\" \\ bye"`},
+		{"single quotes", []QueryFilter{{"org", []string{"O'Brien"}, false}}, `org:"O'Brien"`},
+		{"negated", []QueryFilter{{"asn", []string{"AS1"}, false}, {"net", []string{"10.0.0.0/24", "10.0.1.1"}, true}}, "asn:AS1 -net:10.0.0.0/24,10.0.1.1"},
+	}
+
+	for _, test := range tests {
+		if got := (Query{Filters: test.filters}).String(); got != test.want {
+			t.Errorf("%s: got %s, want %s", test.name, got, test.want)
+		}
+	}
+}
+
+func TestQueryAdd(t *testing.T) {
+	tests := []struct {
+		name   string
+		filter string
+		values []string
+		want   string
+		err    string
+	}{
+		{"asn forms", "asn", []string{"AS15169", "as13335", "8075", " AS15169 "}, "asn:AS15169,AS13335,AS8075", ""},
+		{"hostnames are folded", "hostname", []string{"Mail.Example.COM.", "mail.example.com"}, "hostname:mail.example.com", ""},
+		{"cidrs are masked", "net", []string{"10.0.0.77/24", "10.0.0.0/24"}, "net:10.0.0.0/24", ""},
+		{"mapped ipv6 is unmapped", "net", []string{"::ffff:10.0.0.1"}, "net:10.0.0.1", ""},
+		{"empty values are skipped", "org", []string{"", "  ", "Acme"}, "org:Acme", ""},
+		{"bad asn", "asn", []string{"AS15169", "Google"}, "", `asn "Google": not a number like AS15169`},
+		{"bad hostname", "hostname", []string{"not a host"}, "", `hostname "not a host": not a valid hostname`},
+		{"bad net", "net", []string{"10.0.0.0/33"}, "", `net "10.0.0.0/33": not an ip or cidr`},
+		{"multiline org", "org", []string{"Acme\nInc"}, "", "can't span lines"},
+		{"unknown filter", "port", []string{"22"}, "", `unknown filter "port"`},
+		{"negated", "-hostname", []string{"Example.com"}, "-hostname:example.com", ""},
+		{"bad negated", "-asn", []string{"Google"}, "", `asn "Google": not a number like AS15169`},
+	}
+
+	for _, test := range tests {
+		query := Query{}
+		err := query.Add(test.filter, test.values...)
+		if test.err != "" {
+			if err == nil || !strings.Contains(err.Error(), test.err) {
+				t.Errorf("%s: got error %v, want %q", test.name, err, test.err)
+			}
+			// nothing is added when a value is invalid
+			if !query.Empty() {
+				t.Errorf("%s: the invalid values left %s", test.name, query)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s: %v", test.name, err)
+			continue
+		}
+		if got := query.String(); got != test.want {
+			t.Errorf("%s: got %s, want %s", test.name, got, test.want)
+		}
+	}
+}
+
+func TestParseQuery(t *testing.T) {
+	tests := []struct {
+		text    string
+		filters string
+		err     string
+	}{
+		{"", "[]", ""},
+		{"net:10.0.0.0/24", "[{net [10.0.0.0/24] false}]", ""},
+		{"  net:10.0.0.1,10.0.0.2   asn:AS1  ", "[{net [10.0.0.1 10.0.0.2] false} {asn [AS1] false}]", ""},
+		{`org:"Acme, Inc." asn:AS15169`, "[{org [Acme, Inc.] false} {asn [AS15169] false}]", ""},
+		{`org:"Acme",Beta`, "[{org [Acme Beta] false}]", ""},
+		{`ssl.cert.subject.cn:"say \"hi\" \\ bye"`, `[{ssl.cert.subject.cn [say "hi" \ bye] false}]`, ""},
+		// the same filter twice is read as one
+		{"asn:AS1 asn:AS2", "[{asn [AS1 AS2] false}]", ""},
+		{`net:"2001:db8::/32"`, "[{net [2001:db8::/32] false}]", ""},
+		{`org:"Acme`, "", "quote is never closed"},
+		{"10.0.0.1", "", "is not a filter:value pair"},
+		{"asn:Google", "", `asn "Google": not a number like AS15169`},
+		{"port:22", "", `unknown filter "port"`},
+	}
+
+	for _, test := range tests {
+		query, err := ParseQuery(test.text)
+		if test.err != "" {
+			if err == nil || !strings.Contains(err.Error(), test.err) {
+				t.Errorf("ParseQuery(%q) gave error %v, want %q", test.text, err, test.err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("ParseQuery(%q): %v", test.text, err)
+			continue
+		}
+		if filters := fmt.Sprint(query.Filters); filters != test.filters {
+			t.Errorf("ParseQuery(%q) = %s, want %s", test.text, filters, test.filters)
+		}
+	}
+}
+
+// every query String writes reads back to the same filters
+func TestQueryRoundTrip(t *testing.T) {
+	queries := []Query{
+		{},
+		{Filters: []QueryFilter{{"net", []string{"10.0.0.0/24", "2001:db8::/32"}, false}}},
+		{Filters: []QueryFilter{{"org", []string{"Acme, Inc.", `The "Best" Co`, `back\slash`, "O'Brien: Sons"}, false}, {"asn", []string{"AS15169"}, false}}},
+		{Filters: []QueryFilter{{"ssl.cert.subject.cn", []string{"*.example.com", "vpn example"}, false}, {"hostname", []string{"example.com"}, false}}},
+		{Filters: []QueryFilter{{"net", []string{"10.0.0.0/8"}, false}, {"net", []string{"10.0.0.0/24"}, true}}},
+	}
+
+	for _, query := range queries {
+		text := query.String()
+		parsed, err := ParseQuery(text)
+		if err != nil {
+			t.Errorf("ParseQuery(%q): %v", text, err)
+			continue
+		}
+		if fmt.Sprintf("%#v", parsed.Filters) != fmt.Sprintf("%#v", query.Filters) {
+			t.Errorf("%q read back as %v, want %v", text, parsed.Filters, query.Filters)
+		}
+	}
+}
+
+func TestAddFields(t *testing.T) {
+	query := ParseScope("10.0.0.0/24").Search()
+	err := query.AddFields(map[string]string{
+		"org":      "Acme, Inc.\nBeta LLC\r\n",
+		"hostname": "example.com, mail.example.com;bad host!",
+		"asn":      "AS1 2\nnope",
+	})
+
+	// the valid values are kept even though some aren't
+	if want := `net:10.0.0.0/24 org:"Acme, Inc.","Beta LLC" hostname:example.com,mail.example.com asn:AS1,AS2`; query.String() != want {
+		t.Errorf("got %s, want %s", query.String(), want)
+	}
+	if err == nil || !strings.Contains(err.Error(), `hostname "host!"`) || !strings.Contains(err.Error(), `asn "nope"`) {
+		t.Errorf("got error %v, want the bad hostname and asn listed", err)
+	}
+}
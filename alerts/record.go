@@ -0,0 +1,262 @@
+package alerts
+
+import (
+	"context"
+	"crypto/sha1"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"net/http"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+)
+
+// RecordClient passes every request through to Client and saves the raw
+// response in Dir, so the session can be served again by a ReplayClient
+type RecordClient struct {
+	Client ShodanClient
+	Dir    string
+}
+
+func NewRecordClient(client ShodanClient, dir string) *RecordClient {
+	os.MkdirAll(dir, 0755)
+	return &RecordClient{
+		Client: client,
+		Dir:    dir,
+	}
+}
+
+func (r *RecordClient) Rss() ([]byte, error) {
+	return r.save(rssFile(), r.Client.Rss)
+}
+
+func (r *RecordClient) Alerts() ([]byte, error) {
+	return r.save(alertsFile(), r.Client.Alerts)
+}
+
+// a host shodan had nothing on is saved as a marker, so it can be told apart from one
+// whose request failed
+func (r *RecordClient) Host(ip string) ([]byte, error) {
+	body, err := r.save(hostFile(ip), func() ([]byte, error) {
+		return r.Client.Host(ip)
+	})
+
+	var status *StatusError
+	if errors.As(err, &status) && status.Code == http.StatusNotFound {
+		os.WriteFile(filepath.Join(r.Dir, hostNotFoundFile(ip)), nil, 0644)
+	}
+	return body, err
+}
+
+func (r *RecordClient) Search(query string, page int) ([]byte, error) {
+	return r.save(searchFile(query, page), func() ([]byte, error) {
+		return r.Client.Search(query, page)
+	})
+}
+
+// the stream is copied line for line into the stream file as it is read
+func (r *RecordClient) Stream(ctx context.Context) (io.ReadCloser, error) {
+	stream, err := r.Client.Stream(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	file, err := os.OpenFile(filepath.Join(r.Dir, streamFile()), os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0644)
+	if err != nil {
+		return stream, nil
+	}
+
+	return &recordedStream{stream: stream, file: file}, nil
+}
+
+type recordedStream struct {
+	stream io.ReadCloser
+	file   *os.File
+}
+
+func (r *recordedStream) Read(p []byte) (int, error) {
+	n, err := r.stream.Read(p)
+	r.file.Write(p[:n])
+	return n, err
+}
+
+func (r *recordedStream) Close() error {
+	r.file.Close()
+	return r.stream.Close()
+}
+
+func (r *RecordClient) Triggers() ([]byte, error) {
+	return r.save(triggersFile(), r.Client.Triggers)
+}
+
+// changes to alerts are passed straight through, there is nothing to replay for them
+func (r *RecordClient) CreateAlert(body []byte) ([]byte, error) {
+	return r.Client.CreateAlert(body)
+}
+
+func (r *RecordClient) UpdateAlert(id string, body []byte) ([]byte, error) {
+	return r.Client.UpdateAlert(id, body)
+}
+
+func (r *RecordClient) DeleteAlert(id string) error {
+	return r.Client.DeleteAlert(id)
+}
+
+func (r *RecordClient) EnableTrigger(id string, trigger string) error {
+	return r.Client.EnableTrigger(id, trigger)
+}
+
+func (r *RecordClient) DisableTrigger(id string, trigger string) error {
+	return r.Client.DisableTrigger(id, trigger)
+}
+
+func (r *RecordClient) ForceRefresh() ShodanClient {
+	return &RecordClient{
+		Client: ForceRefresh(r.Client),
+		Dir:    r.Dir,
+	}
+}
+
+// only successful responses are saved, a failed request replays as a missing file
+func (r *RecordClient) save(name string, request func() ([]byte, error)) ([]byte, error) {
+	body, err := request()
+	if err != nil {
+		return body, err
+	}
+
+	os.WriteFile(filepath.Join(r.Dir, name), body, 0644)
+	return body, nil
+}
+
+// ReplayClient serves the responses saved by a RecordClient without touching the network
+type ReplayClient struct {
+	Dir string
+}
+
+func NewReplayClient(dir string) *ReplayClient {
+	return &ReplayClient{
+		Dir: dir,
+	}
+}
+
+func (r *ReplayClient) Rss() ([]byte, error) {
+	return r.load(rssFile())
+}
+
+func (r *ReplayClient) Alerts() ([]byte, error) {
+	return r.load(alertsFile())
+}
+
+// ErrNotRecorded is returned for a request the recording has no response for, either
+// because it was never made or because it failed while recording
+var ErrNotRecorded = errors.New("not in the recording")
+
+// a host recorded as a 404 replays as one, any other missing host was never recorded
+func (r *ReplayClient) Host(ip string) ([]byte, error) {
+	body, err := r.load(hostFile(ip))
+	if !errors.Is(err, fs.ErrNotExist) {
+		return body, err
+	}
+
+	if _, statErr := os.Stat(filepath.Join(r.Dir, hostNotFoundFile(ip))); statErr == nil {
+		return nil, &StatusError{Code: http.StatusNotFound, Status: "404 Not Found"}
+	}
+	return nil, fmt.Errorf("host %s %w", ip, ErrNotRecorded)
+}
+
+func (r *ReplayClient) Search(query string, page int) ([]byte, error) {
+	return r.load(searchFile(query, page))
+}
+
+// replays the recorded stream once, it ends with ErrStreamEnded so the consumer stops
+// instead of reading it again
+func (r *ReplayClient) Stream(ctx context.Context) (io.ReadCloser, error) {
+	file, err := os.Open(filepath.Join(r.Dir, streamFile()))
+	if err != nil {
+		return nil, fmt.Errorf("%w, nothing was recorded: %w", ErrStreamEnded, err)
+	}
+	return replayedStream{file}, nil
+}
+
+type replayedStream struct {
+	*os.File
+}
+
+func (r replayedStream) Read(p []byte) (int, error) {
+	n, err := r.File.Read(p)
+	if err == io.EOF {
+		err = ErrStreamEnded
+	}
+	return n, err
+}
+
+func (r *ReplayClient) Triggers() ([]byte, error) {
+	return r.load(triggersFile())
+}
+
+var ErrReplayReadOnly = errors.New("alerts can't be changed while replaying a recorded session")
+
+func (r *ReplayClient) CreateAlert(body []byte) ([]byte, error) {
+	return nil, ErrReplayReadOnly
+}
+
+func (r *ReplayClient) UpdateAlert(id string, body []byte) ([]byte, error) {
+	return nil, ErrReplayReadOnly
+}
+
+func (r *ReplayClient) DeleteAlert(id string) error {
+	return ErrReplayReadOnly
+}
+
+func (r *ReplayClient) EnableTrigger(id string, trigger string) error {
+	return ErrReplayReadOnly
+}
+
+func (r *ReplayClient) DisableTrigger(id string, trigger string) error {
+	return ErrReplayReadOnly
+}
+
+func (r *ReplayClient) load(name string) ([]byte, error) {
+	return os.ReadFile(filepath.Join(r.Dir, name))
+}
+
+var unsafeFileChars = regexp.MustCompile(`[^A-Za-z0-9._-]`)
+
+func rssFile() string {
+	return "rss.xml"
+}
+
+func alertsFile() string {
+	return "alerts.json"
+}
+
+func triggersFile() string {
+	return "triggers.json"
+}
+
+func streamFile() string {
+	return "stream.ndjson"
+}
+
+func hostFile(ip string) string {
+	return "host-" + unsafeFileChars.ReplaceAllString(ip, "_") + ".json"
+}
+
+// marks a host shodan answered 404 for
+func hostNotFoundFile(ip string) string {
+	return "host-" + unsafeFileChars.ReplaceAllString(ip, "_") + ".404"
+}
+
+// queries can be long and full of filter characters, so they are hashed instead
+func searchFile(query string, page int) string {
+	return "search-" + hashKey(query) + "-" + strconv.Itoa(page) + ".json"
+}
+
+func hashKey(key string) string {
+	sum := sha1.Sum([]byte(key))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,68 @@
+package alerts
+
+import (
+	"errors"
+	"net/http"
+	"testing"
+)
+
+func TestReplayHosts(t *testing.T) {
+	server := newShodanServer(t, map[string]http.HandlerFunc{
+		"/shodan/host/192.0.2.1": jsonBody(`{"ports": [22], "data": [{"port": 22, "transport": "tcp", "product": "OpenSSH"}]}`),
+		"/shodan/host/192.0.2.2": func(w http.ResponseWriter, r *http.Request) {
+			http.Error(w, `{"error": "No information available for that IP."}`, http.StatusNotFound)
+		},
+	})
+
+	dir := t.TempDir()
+	record := NewRecordClient(server, dir)
+	for _, ip := range []string{"192.0.2.1", "192.0.2.2"} {
+		NewEventFromIp(ip).Load(record)
+	}
+
+	replay := NewReplayClient(dir)
+
+	found := NewEventFromIp("192.0.2.1")
+	found.Load(replay)
+	if found.Incomplete() || len(found.Ports()) != 1 {
+		t.Errorf("replayed 192.0.2.1 has ports %v and errors %v, want port 22", found.Ports(), found.Errors())
+	}
+
+	// shodan had nothing on the host while recording, so the replay has nothing either
+	missing := NewEventFromIp("192.0.2.2")
+	missing.Load(replay)
+	if missing.Incomplete() || len(missing.Ports()) != 0 {
+		t.Errorf("replayed 192.0.2.2 has ports %v and errors %v, want an empty host", missing.Ports(), missing.Errors())
+	}
+
+	var status *StatusError
+	if _, err := replay.Host("192.0.2.2"); !errors.As(err, &status) || status.Code != http.StatusNotFound {
+		t.Errorf("replayed host lookup gave %v, want a 404", err)
+	}
+}
+
+func TestReplayFailedHost(t *testing.T) {
+	server := newShodanServer(t, map[string]http.HandlerFunc{
+		"/shodan/host/192.0.2.3": func(w http.ResponseWriter, r *http.Request) {
+			http.Error(w, `{"error": "Internal error"}`, http.StatusInternalServerError)
+		},
+	})
+
+	dir := t.TempDir()
+	NewEventFromIp("192.0.2.3").Load(NewRecordClient(server, dir))
+
+	replay := NewReplayClient(dir)
+
+	// the request failed while recording, so the replay can't say the host is empty
+	failed := NewEventFromIp("192.0.2.3")
+	failed.Load(replay)
+	if !failed.Incomplete() {
+		t.Error("replayed 192.0.2.3 failed while recording but loaded as complete")
+	}
+
+	for _, ip := range []string{"192.0.2.3", "192.0.2.4"} {
+		if _, err := replay.Host(ip); !errors.Is(err, ErrNotRecorded) {
+			t.Errorf("replayed host lookup of %s gave %v, want ErrNotRecorded", ip, err)
+		}
+	}
+}
@@ -0,0 +1,120 @@
+package alerts
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/netip"
+	"os"
+	"regexp"
+	"sort"
+	"sync"
+	"time"
+)
+
+const resolveTimeout = 5 * time.Second
+
+// HostMapping is a hostname from an address field and the addresses it resolved to
+type HostMapping struct {
+	Host  string
+	Addrs []netip.Addr
+}
+
+// the resolved addresses written out, for listing in reports
+func (h HostMapping) Ips() []string {
+	ips := []string{}
+	for _, addr := range h.Addrs {
+		ips = append(ips, addr.String())
+	}
+	return ips
+}
+
+// labels of letters, digits and dashes joined by dots, the last label starting with a letter
+// so ips and short ranges like 10.0.0.1-9 are never taken as hostnames
+var hostnamePattern = regexp.MustCompile(`(?i)^([a-z0-9_]([a-z0-9_-]{0,61}[a-z0-9])?\.)+[a-z]([a-z0-9-]{0,61}[a-z0-9])?\.?$`)
+
+func isHostname(token string) bool {
+	return len(token) <= 253 && hostnamePattern.MatchString(token)
+}
+
+var (
+	resolver     *net.Resolver
+	resolverOnce sync.Once
+)
+
+// Resolver looks up hostnames through the dns server in DNS_RESOLVER, a host with an
+// optional port, or through the system resolver when it is unset
+func Resolver() *net.Resolver {
+	resolverOnce.Do(func() {
+		addr := os.Getenv("DNS_RESOLVER")
+		if addr == "" {
+			resolver = net.DefaultResolver
+			return
+		}
+
+		if _, _, err := net.SplitHostPort(addr); err != nil {
+			addr = net.JoinHostPort(addr, "53")
+		}
+
+		resolver = &net.Resolver{
+			PreferGo: true,
+			Dial: func(ctx context.Context, network string, _ string) (net.Conn, error) {
+				dialer := net.Dialer{}
+				return dialer.DialContext(ctx, network, addr)
+			},
+		}
+	})
+	return resolver
+}
+
+// finds the A and AAAA records of a hostname
+func resolveHost(host string) ([]netip.Addr, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), resolveTimeout)
+	defer cancel()
+
+	addrs, err := Resolver().LookupNetIP(ctx, "ip", host)
+	if err != nil {
+		var dnsErr *net.DNSError
+		if errors.As(err, &dnsErr) && dnsErr.IsNotFound {
+			return nil, errors.New("hostname not found")
+		}
+		return nil, errors.New("could not resolve hostname")
+	}
+	if len(addrs) == 0 {
+		return nil, errors.New("hostname has no A or AAAA records")
+	}
+
+	for i := range addrs {
+		addrs[i] = addrs[i].Unmap()
+	}
+	sort.Slice(addrs, func(i, j int) bool {
+		return addrs[i].Less(addrs[j])
+	})
+	return addrs, nil
+}
+
+// gives each event the hostnames from the scope that resolved to its ip
+func (s Scope) LabelHosts(events []*Event) {
+	for _, e := range events {
+		addr, err := netip.ParseAddr(e.Ip)
+		if err != nil {
+			continue
+		}
+		e.Hostnames = s.HostsOf(addr)
+	}
+}
+
+// the hostnames of the scope that resolved to the address
+func (s Scope) HostsOf(addr netip.Addr) []string {
+	addr = addr.Unmap()
+	hosts := []string{}
+	for _, mapping := range s.Hosts {
+		for _, a := range mapping.Addrs {
+			if a == addr {
+				hosts = append(hosts, mapping.Host)
+				break
+			}
+		}
+	}
+	return hosts
+}
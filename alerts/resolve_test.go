@@ -0,0 +1,170 @@
+package alerts
+
+import (
+	"encoding/binary"
+	"fmt"
+	"net"
+	"net/netip"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// a dns server on a local udp port answering A and AAAA questions from records, names it
+// doesn't know get NXDOMAIN. Resolver is pointed at it for the rest of the test
+func useDnsServer(t *testing.T, records map[string][]string) {
+	t.Helper()
+
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { conn.Close() })
+
+	go func() {
+		buf := make([]byte, 1500)
+		for {
+			n, from, err := conn.ReadFrom(buf)
+			if err != nil {
+				return
+			}
+			if reply := dnsReply(buf[:n], records); reply != nil {
+				conn.WriteTo(reply, from)
+			}
+		}
+	}()
+
+	t.Setenv("DNS_RESOLVER", conn.LocalAddr().String())
+	resolverOnce = sync.Once{}
+	t.Cleanup(func() { resolverOnce = sync.Once{} })
+}
+
+func dnsReply(query []byte, records map[string][]string) []byte {
+	if len(query) < 12 {
+		return nil
+	}
+
+	// the name is a run of length prefixed labels ending with an empty one
+	labels := []string{}
+	end := 12
+	for end < len(query) && query[end] != 0 {
+		length := int(query[end])
+		if end+1+length > len(query) {
+			return nil
+		}
+		labels = append(labels, string(query[end+1:end+1+length]))
+		end += 1 + length
+	}
+	if end+5 > len(query) {
+		return nil
+	}
+	name := strings.ToLower(strings.Join(labels, "."))
+	qtype := binary.BigEndian.Uint16(query[end+1:])
+	question := query[12 : end+5]
+
+	addrs, known := records[name]
+	answers := [][]byte{}
+	for _, a := range addrs {
+		addr := netip.MustParseAddr(a)
+		if (qtype == 1 && !addr.Is4()) || (qtype == 28 && !addr.Is6()) || (qtype != 1 && qtype != 28) {
+			continue
+		}
+		data := addr.AsSlice()
+		// a pointer back to the name in the question, class IN and a minute ttl
+		answer := []byte{0xc0, 12}
+		answer = binary.BigEndian.AppendUint16(answer, qtype)
+		answer = append(answer, 0, 1, 0, 0, 0, 60)
+		answer = binary.BigEndian.AppendUint16(answer, uint16(len(data)))
+		answers = append(answers, append(answer, data...))
+	}
+
+	flags := uint16(0x8180)
+	if !known {
+		flags |= 3
+	}
+	reply := append([]byte{}, query[0:2]...)
+	reply = binary.BigEndian.AppendUint16(reply, flags)
+	reply = binary.BigEndian.AppendUint16(reply, 1)
+	reply = binary.BigEndian.AppendUint16(reply, uint16(len(answers)))
+	reply = append(reply, 0, 0, 0, 0)
+	reply = append(reply, question...)
+	for _, answer := range answers {
+		reply = append(reply, answer...)
+	}
+	return reply
+}
+
+func TestParseScopeHostnames(t *testing.T) {
+	useDnsServer(t, map[string][]string{
+		"app.example.test": {"192.0.2.10", "2001:db8::10"},
+		"www.example.test": {"192.0.2.10"},
+		"db.example.test":  {"192.0.2.20"},
+	})
+
+	scope := ParseScope("app.example.test, WWW.example.test db.example.test missing.example.test 192.0.2.30")
+
+	if ranges := fmt.Sprint(scope.Strings()); ranges != "[192.0.2.10 192.0.2.20 192.0.2.30 2001:db8::10]" {
+		t.Errorf("the scope ranges are %s", ranges)
+	}
+
+	hosts := []string{}
+	for _, mapping := range scope.Hosts {
+		hosts = append(hosts, mapping.Host+" "+strings.Join(mapping.Ips(), ","))
+	}
+	if want := "[app.example.test 192.0.2.10,2001:db8::10 WWW.example.test 192.0.2.10 db.example.test 192.0.2.20]"; fmt.Sprint(hosts) != want {
+		t.Errorf("the scope hosts are %v, want %s", hosts, want)
+	}
+
+	if err := scope.Err(); err == nil || err.Error() != `invalid addresses "missing.example.test": hostname not found` {
+		t.Errorf("the scope error is %v", err)
+	}
+
+	tests := []struct {
+		ip    string
+		hosts string
+	}{
+		{"192.0.2.10", "[app.example.test WWW.example.test]"},
+		{"2001:db8::10", "[app.example.test]"},
+		{"192.0.2.20", "[db.example.test]"},
+		// typed in as an ip, so no hostname names it
+		{"192.0.2.30", "[]"},
+	}
+
+	events := []*Event{}
+	for _, test := range tests {
+		events = append(events, NewEventFromIp(test.ip))
+	}
+	scope.LabelHosts(events)
+
+	for i, test := range tests {
+		if hosts := fmt.Sprint(events[i].Hostnames); hosts != test.hosts {
+			t.Errorf("%s is labeled %s, want %s", test.ip, hosts, test.hosts)
+		}
+	}
+}
+
+func TestIsHostname(t *testing.T) {
+	tests := []struct {
+		token string
+		want  bool
+	}{
+		{"example.com", true},
+		{"mail.example.com.", true},
+		{"_dmarc.example.com", true},
+		{"xn--bcher-kva.example", true},
+		{"localhost", false},
+		{"10.0.0.1", false},
+		{"10.0.0.1-9", false},
+		{"10.0.0.0/24", false},
+		{"2001:db8::1", false},
+		{"-bad.example.com", false},
+		{"example.123", false},
+		{strings.Repeat("a", 64) + ".com", false},
+	}
+
+	for _, test := range tests {
+		if got := isHostname(test.token); got != test.want {
+			t.Errorf("isHostname(%q) = %v, want %v", test.token, got, test.want)
+		}
+	}
+}
@@ -0,0 +1,139 @@
+package alerts
+
+import (
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"net/netip"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type Item struct {
+	Text        string `xml:",chardata"`
+	Title       string `xml:"title"`
+	Link        string `xml:"link"`
+	Description string `xml:"description"`
+	Guid        struct {
+		Text string `xml:",chardata"`
+	} `xml:"guid"`
+	PubDate string `xml:"pubDate"`
+}
+
+type Rss struct {
+	Channel struct {
+		Item []Item `xml:"item"`
+	} `xml:"channel"`
+}
+
+// QuarantinedItem is a feed item that could not be parsed, it is kept so it can be reviewed by hand
+type QuarantinedItem struct {
+	Item   Item
+	Reason string
+}
+
+// titles look like "1.2.3.4 on port 3389 ... `trigger`"
+var titlePattern = regexp.MustCompile("^\\s*(\\S+)\\s.*?\\bport\\s+(\\d{1,5})\\b.*`([^`]+)`\\s*$")
+
+func NewEventFromItem(item Item) (*Event, error) {
+	match := titlePattern.FindStringSubmatch(item.Title)
+	if match == nil {
+		return nil, errors.New("title does not match \"<ip> ... port <port> ... `<trigger>`\"")
+	}
+
+	addr, err := netip.ParseAddr(match[1])
+	if err != nil {
+		return nil, fmt.Errorf("invalid ip %q", match[1])
+	}
+	ip := addr.String()
+
+	port, err := strconv.Atoi(match[2])
+	if err != nil || port > 65535 {
+		return nil, fmt.Errorf("invalid port %q", match[2])
+	}
+	trigger := strings.TrimSpace(match[3])
+
+	timestamp, err := parsePubDate(item.PubDate)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Event{
+		Ip:          ip,
+		Trigger:     trigger,
+		TriggerPort: port,
+		AlertLink:   item.Link,
+		HostLink:    "https://www.shodan.io/host/" + ip,
+		Desc:        item.Description + " on port " + strconv.Itoa(port),
+		Timestamp:   timestamp,
+		ports:       make(map[int][]Cve),
+		services:    make(map[int]Service),
+		done:        make(chan struct{}),
+	}, nil
+}
+
+func parsePubDate(pubDate string) (time.Time, error) {
+	pubDate = strings.TrimSpace(pubDate)
+	for _, layout := range []string{time.RFC1123Z, time.RFC1123} {
+		timestamp, err := time.Parse(layout, pubDate)
+		if err == nil {
+			return timestamp, nil
+		}
+	}
+
+	return time.Time{}, fmt.Errorf("invalid pubDate %q", pubDate)
+}
+
+// ParseRss turns each feed item into an event, items that can't be parsed are quarantined instead
+func ParseRss(body []byte) ([]*Event, []QuarantinedItem, error) {
+	var rss Rss
+	if err := xml.Unmarshal(body, &rss); err != nil {
+		return nil, nil, err
+	}
+
+	events := []*Event{}
+	quarantined := []QuarantinedItem{}
+
+	for _, item := range rss.Channel.Item {
+		event, err := NewEventFromItem(item)
+		if err != nil {
+			quarantined = append(quarantined, QuarantinedItem{Item: item, Reason: err.Error()})
+			continue
+		}
+		events = append(events, event)
+	}
+
+	return events, quarantined, nil
+}
+
+func DownloadRss(client ShodanClient) ([]*Event, []QuarantinedItem) {
+	cache := NewEventCache()
+
+	body, err := client.Rss()
+	if err != nil {
+		fmt.Println("Error: downloading rss", err.Error())
+		return []*Event{}, []QuarantinedItem{}
+	}
+
+	feedEvents, quarantined, err := ParseRss(body)
+	if err != nil {
+		fmt.Println("Error: parsing rss", err.Error())
+		return []*Event{}, []QuarantinedItem{}
+	}
+
+	events := []*Event{}
+	index, alertErr := DownloadAlerts(client)
+
+	// checks if an event has been seen recently, and if now add it to the list
+	for _, newEvent := range feedEvents {
+		if cache.HasEventBeenSeen(newEvent) == false {
+			newEvent.matchAlert(index, alertErr)
+			events = append(events, newEvent)
+			cache.InsertEvent(newEvent)
+		}
+	}
+
+	return events, quarantined
+}
@@ -0,0 +1,75 @@
+package alerts
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestNewEventFromItem(t *testing.T) {
+	const pubDate = "Mon, 12 Oct 2026 10:00:00 +0000"
+
+	tests := []struct {
+		title   string
+		pubDate string
+		ip      string
+		port    int
+		trigger string
+		reason  string
+	}{
+		{"198.51.100.10 on port 3389 matched `open_database`", pubDate, "198.51.100.10", 3389, "open_database", ""},
+		{"  198.51.100.10 is listening on port 22, matching `end_of_life`  ", pubDate, "198.51.100.10", 22, "end_of_life", ""},
+		// several triggers come through as one comma separated list
+		{"198.51.100.10 on port 443 matched `ssl_expired,vulnerable`", pubDate, "198.51.100.10", 443, "ssl_expired,vulnerable", ""},
+		{"2001:db8::1 on port 80 matched `malware`", pubDate, "2001:db8::1", 80, "malware", ""},
+		// the older rfc 1123 dates without a numeric zone still parse
+		{"198.51.100.10 on port 80 matched `malware`", "Mon, 12 Oct 2026 10:00:00 UTC", "198.51.100.10", 80, "malware", ""},
+		// "report" ends with "port" but is not the port
+		{"198.51.100.10 in report 8080 matched `malware`", pubDate, "", 0, "", "title does not match"},
+		{"a title with no address in it", pubDate, "", 0, "", "title does not match"},
+		{"198.51.100.10 on port 80 matched malware", pubDate, "", 0, "", "title does not match"},
+		{"198.51.100 on port 80 matched `malware`", pubDate, "", 0, "", `invalid ip "198.51.100"`},
+		{"198.51.100.10 on port 70000 matched `malware`", pubDate, "", 0, "", `invalid port "70000"`},
+		{"198.51.100.10 on port 80 matched `malware`", "yesterday", "", 0, "", `invalid pubDate "yesterday"`},
+	}
+
+	for _, test := range tests {
+		e, err := NewEventFromItem(Item{Title: test.title, PubDate: test.pubDate, Description: "Found"})
+		if test.reason != "" {
+			if err == nil || !strings.Contains(err.Error(), test.reason) {
+				t.Errorf("%q gave error %v, want %q", test.title, err, test.reason)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%q: %v", test.title, err)
+			continue
+		}
+		if e.Ip != test.ip || e.TriggerPort != test.port || e.Trigger != test.trigger {
+			t.Errorf("%q is %s:%d %q, want %s:%d %q", test.title, e.Ip, e.TriggerPort, e.Trigger, test.ip, test.port, test.trigger)
+		}
+		if want := time.Date(2026, 10, 12, 10, 0, 0, 0, time.UTC); !e.Timestamp.Equal(want) {
+			t.Errorf("%q has timestamp %v, want %v", test.title, e.Timestamp, want)
+		}
+	}
+}
+
+func TestParseRss(t *testing.T) {
+	events, quarantined, err := ParseRss([]byte(testRss))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(events) != 2 || len(quarantined) != 1 {
+		t.Fatalf("got %d events and %d quarantined items, want 2 and 1", len(events), len(quarantined))
+	}
+	if events[0].Desc != "Open database on port 3389" || events[0].AlertLink != "https://monitor.shodan.io/events/1" {
+		t.Errorf("first event has description %q and link %q", events[0].Desc, events[0].AlertLink)
+	}
+	if item := quarantined[0]; item.Item.Title != "a title with no address in it" || item.Reason == "" {
+		t.Errorf("quarantined %q for %q", item.Item.Title, item.Reason)
+	}
+
+	if _, _, err := ParseRss([]byte("<rss><channel>")); err == nil {
+		t.Error("a truncated feed parsed without an error")
+	}
+}
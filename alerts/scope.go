@@ -0,0 +1,275 @@
+package alerts
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+	"net/netip"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// AddrRange is every address from From to To, both included
+type AddrRange struct {
+	From netip.Addr
+	To   netip.Addr
+}
+
+// ScopeError is a token of a scope that could not be read
+type ScopeError struct {
+	Token  string
+	Reason string
+}
+
+func (e ScopeError) Error() string {
+	return strconv.Quote(e.Token) + ": " + e.Reason
+}
+
+// Scope is a set of addresses read from user input, kept as sorted ranges with no overlaps.
+// Hosts keeps which addresses came from each hostname so reports can show the mapping
+type Scope struct {
+	Ranges []AddrRange
+	Hosts  []HostMapping
+	Errors []ScopeError
+}
+
+// spaces around the dash of a range are dropped so the range stays one token
+var rangeDashPattern = regexp.MustCompile(`\s*-\s*`)
+
+// ParseScope reads single ips, cidrs like 10.0.0.0/24, dash ranges like 10.0.0.1-10.0.0.9
+// or 10.0.0.1-9, ipv6 in any of those forms and hostnames, separated by commas, semicolons
+// or spaces. Hostnames are resolved to their A and AAAA records through Resolver.
+// Tokens that can't be read are kept in Errors and left out of the ranges
+func ParseScope(input string) Scope {
+	scope := Scope{}
+
+	input = rangeDashPattern.ReplaceAllString(input, "-")
+	tokens := strings.FieldsFunc(input, func(r rune) bool {
+		return r == ',' || r == ';' || r == ' ' || r == '\t' || r == '\n' || r == '\r'
+	})
+
+	for _, token := range tokens {
+		if isHostname(token) {
+			addrs, err := resolveHost(token)
+			if err != nil {
+				scope.Errors = append(scope.Errors, ScopeError{Token: token, Reason: err.Error()})
+				continue
+			}
+			scope.Hosts = append(scope.Hosts, HostMapping{Host: token, Addrs: addrs})
+			for _, addr := range addrs {
+				scope.Ranges = append(scope.Ranges, AddrRange{From: addr, To: addr})
+			}
+			continue
+		}
+
+		r, err := parseScopeToken(token)
+		if err != nil {
+			scope.Errors = append(scope.Errors, ScopeError{Token: token, Reason: err.Error()})
+			continue
+		}
+		scope.Ranges = append(scope.Ranges, r)
+	}
+
+	scope.Ranges = mergeRanges(scope.Ranges)
+	return scope
+}
+
+func parseScopeToken(token string) (AddrRange, error) {
+	if strings.Contains(token, "/") {
+		prefix, err := netip.ParsePrefix(token)
+		if err != nil {
+			return AddrRange{}, errors.New("not a valid cidr")
+		}
+		prefix = prefix.Masked()
+		return AddrRange{From: prefix.Addr(), To: lastAddr(prefix)}, nil
+	}
+
+	if from, to, ok := strings.Cut(token, "-"); ok {
+		start, err := netip.ParseAddr(from)
+		if err != nil {
+			return AddrRange{}, fmt.Errorf("%q is not a valid ip", from)
+		}
+
+		end, err := netip.ParseAddr(to)
+		if err != nil {
+			// the short form 10.0.0.1-9 only gives the last octet of the end
+			octet, octetErr := strconv.Atoi(to)
+			if !start.Is4() || octetErr != nil || octet < 0 || octet > 255 {
+				return AddrRange{}, fmt.Errorf("%q is not a valid ip", to)
+			}
+			bytes := start.As4()
+			bytes[3] = byte(octet)
+			end = netip.AddrFrom4(bytes)
+		}
+
+		// mapped ipv6 like ::ffff:10.0.0.1 is the ipv4 address it holds
+		start, end = start.Unmap(), end.Unmap()
+		if start.Is4() != end.Is4() {
+			return AddrRange{}, errors.New("range mixes ipv4 and ipv6")
+		}
+		if end.Less(start) {
+			return AddrRange{}, errors.New("range ends before it starts")
+		}
+		return AddrRange{From: start, To: end}, nil
+	}
+
+	addr, err := netip.ParseAddr(token)
+	if err != nil {
+		return AddrRange{}, errors.New("not an ip, cidr, range or hostname")
+	}
+	addr = addr.Unmap()
+	return AddrRange{From: addr, To: addr}, nil
+}
+
+// sorts the ranges and joins the ones that overlap or touch
+func mergeRanges(ranges []AddrRange) []AddrRange {
+	sort.Slice(ranges, func(i, j int) bool {
+		return ranges[i].From.Less(ranges[j].From)
+	})
+
+	merged := []AddrRange{}
+	for _, r := range ranges {
+		if len(merged) > 0 {
+			last := &merged[len(merged)-1]
+			next := last.To.Next()
+			if last.From.Is4() == r.From.Is4() && (!next.IsValid() || !next.Less(r.From)) {
+				if last.To.Less(r.To) {
+					last.To = r.To
+				}
+				continue
+			}
+		}
+		merged = append(merged, r)
+	}
+
+	return merged
+}
+
+// the last address in the prefix
+func lastAddr(prefix netip.Prefix) netip.Addr {
+	bytes := prefix.Addr().AsSlice()
+	bits := prefix.Bits()
+	for i := range bytes {
+		for b := 0; b < 8; b++ {
+			if i*8+b >= bits {
+				bytes[i] |= 0x80 >> b
+			}
+		}
+	}
+
+	addr, _ := netip.AddrFromSlice(bytes)
+	return addr
+}
+
+// the fewest cidrs that cover the range exactly
+func (r AddrRange) Prefixes() []netip.Prefix {
+	prefixes := []netip.Prefix{}
+
+	for from := r.From; from.IsValid() && !r.To.Less(from); {
+		bits := from.BitLen()
+		// widen the prefix while it still starts at from and ends inside the range
+		for bits > 0 {
+			wider := netip.PrefixFrom(from, bits-1).Masked()
+			if wider.Addr() != from || r.To.Less(lastAddr(wider)) {
+				break
+			}
+			bits--
+		}
+
+		prefix := netip.PrefixFrom(from, bits)
+		prefixes = append(prefixes, prefix)
+		from = lastAddr(prefix).Next()
+	}
+
+	return prefixes
+}
+
+// the range as a single ip, a cidr when it is one or a dash range
+func (r AddrRange) String() string {
+	if r.From == r.To {
+		return r.From.String()
+	}
+
+	prefixes := r.Prefixes()
+	if len(prefixes) == 1 {
+		return prefixes[0].String()
+	}
+
+	return r.From.String() + "-" + r.To.String()
+}
+
+func (r AddrRange) Count() *big.Int {
+	from := new(big.Int).SetBytes(r.From.AsSlice())
+	to := new(big.Int).SetBytes(r.To.AsSlice())
+	return to.Sub(to, from).Add(to, big.NewInt(1))
+}
+
+// the number of addresses in the scope
+func (s Scope) Count() *big.Int {
+	count := big.NewInt(0)
+	for _, r := range s.Ranges {
+		count.Add(count, r.Count())
+	}
+	return count
+}
+
+// every range of the scope written out, for listing in reports
+func (s Scope) Strings() []string {
+	strs := []string{}
+	for _, r := range s.Ranges {
+		strs = append(strs, r.String())
+	}
+	return strs
+}
+
+// the cidrs covering the scope, shodan and alert filters only take ips and cidrs
+func (s Scope) Cidrs() []string {
+	cidrs := []string{}
+	for _, r := range s.Ranges {
+		for _, prefix := range r.Prefixes() {
+			if prefix.IsSingleIP() {
+				cidrs = append(cidrs, prefix.Addr().String())
+			} else {
+				cidrs = append(cidrs, prefix.String())
+			}
+		}
+	}
+	return cidrs
+}
+
+// Search is a query matching every address in the scope, more filters can be added to narrow it
+func (s Scope) Search() Query {
+	query := Query{}
+	query.Add("net", s.Cidrs()...)
+	return query
+}
+
+// Query is the shodan search for every address in the scope, empty when the scope is
+func (s Scope) Query() string {
+	return s.Search().String()
+}
+
+func (s Scope) Contains(addr netip.Addr) bool {
+	addr = addr.Unmap()
+	for _, r := range s.Ranges {
+		if !addr.Less(r.From) && !r.To.Less(addr) {
+			return true
+		}
+	}
+	return false
+}
+
+// every token that couldn't be read, nil when there were none
+func (s Scope) Err() error {
+	if len(s.Errors) == 0 {
+		return nil
+	}
+
+	errs := []string{}
+	for _, e := range s.Errors {
+		errs = append(errs, e.Error())
+	}
+	return errors.New("invalid addresses " + strings.Join(errs, ", "))
+}
@@ -0,0 +1,104 @@
+package alerts
+
+import (
+	"fmt"
+	"net/netip"
+	"strings"
+	"testing"
+)
+
+func TestParseScope(t *testing.T) {
+	tests := []struct {
+		input  string
+		ranges string
+		errors string
+	}{
+		{"10.0.0.1", "[10.0.0.1]", ""},
+		{"10.0.0.0/24", "[10.0.0.0/24]", ""},
+		// cidrs are masked to their network
+		{"10.0.0.77/24", "[10.0.0.0/24]", ""},
+		{"10.0.0.1-10.0.0.9", "[10.0.0.1-10.0.0.9]", ""},
+		{"10.0.0.1-9", "[10.0.0.1-10.0.0.9]", ""},
+		{"10.0.0.1 - 10.0.0.3", "[10.0.0.1-10.0.0.3]", ""},
+		// separators can be mixed
+		{"10.0.0.1, 10.0.0.3;10.0.0.5\n10.0.0.7\t10.0.0.9", "[10.0.0.1 10.0.0.3 10.0.0.5 10.0.0.7 10.0.0.9]", ""},
+		// overlapping and touching ranges merge, out of order input is sorted
+		{"10.0.0.128/25 10.0.0.0/25", "[10.0.0.0/24]", ""},
+		{"10.0.0.5-20 10.0.0.10-30", "[10.0.0.5-10.0.0.30]", ""},
+		{"10.0.0.2 10.0.0.1 10.0.0.3", "[10.0.0.1-10.0.0.3]", ""},
+		{"10.0.0.1 10.0.0.1", "[10.0.0.1]", ""},
+		{"255.255.255.254 255.255.255.255", "[255.255.255.254/31]", ""},
+		// mapped ipv6 is read as the ipv4 address it holds
+		{"::ffff:10.0.0.1", "[10.0.0.1]", ""},
+		{"::ffff:10.0.0.1-10.0.0.4", "[10.0.0.1-10.0.0.4]", ""},
+		{"2001:db8::/64 2001:db8::1", "[2001:db8::/64]", ""},
+		{"2001:db8::1-2001:db8::ff", "[2001:db8::1-2001:db8::ff]", ""},
+		// ipv4 and ipv6 never merge, ipv4 sorts first
+		{"2001:db8::1 10.0.0.1", "[10.0.0.1 2001:db8::1]", ""},
+		{"10.0.0.9-10.0.0.1", "[]", `"10.0.0.9-10.0.0.1": range ends before it starts`},
+		{"10.0.0.9-1", "[]", `"10.0.0.9-1": range ends before it starts`},
+		{"10.0.0.1-2001:db8::1", "[]", `"10.0.0.1-2001:db8::1": range mixes ipv4 and ipv6`},
+		{"10.0.0.1-256", "[]", `"10.0.0.1-256": "256" is not a valid ip`},
+		{"10.0.0.0/33", "[]", `"10.0.0.0/33": not a valid cidr`},
+		{"10.0.0.1 999.0.0.1", "[10.0.0.1]", `"999.0.0.1": not an ip, cidr, range or hostname`},
+		{"", "[]", ""},
+	}
+
+	for _, test := range tests {
+		scope := ParseScope(test.input)
+
+		if ranges := fmt.Sprint(scope.Strings()); ranges != test.ranges {
+			t.Errorf("ParseScope(%q) ranges are %s, want %s", test.input, ranges, test.ranges)
+		}
+
+		errs := []string{}
+		for _, err := range scope.Errors {
+			errs = append(errs, err.Error())
+		}
+		if strings.Join(errs, ", ") != test.errors {
+			t.Errorf("ParseScope(%q) errors are %q, want %q", test.input, errs, test.errors)
+		}
+	}
+}
+
+func TestAddrRangePrefixes(t *testing.T) {
+	tests := []struct {
+		from     string
+		to       string
+		prefixes string
+	}{
+		{"10.0.0.1", "10.0.0.1", "[10.0.0.1/32]"},
+		{"10.0.0.0", "10.0.0.255", "[10.0.0.0/24]"},
+		{"10.0.0.1", "10.0.0.9", "[10.0.0.1/32 10.0.0.2/31 10.0.0.4/30 10.0.0.8/31]"},
+		{"10.0.0.255", "10.0.1.0", "[10.0.0.255/32 10.0.1.0/32]"},
+		{"0.0.0.0", "255.255.255.255", "[0.0.0.0/0]"},
+		{"255.255.255.254", "255.255.255.255", "[255.255.255.254/31]"},
+		{"2001:db8::", "2001:db8::ffff", "[2001:db8::/112]"},
+		{"2001:db8::1", "2001:db8::3", "[2001:db8::1/128 2001:db8::2/127]"},
+	}
+
+	for _, test := range tests {
+		r := AddrRange{From: netip.MustParseAddr(test.from), To: netip.MustParseAddr(test.to)}
+		if prefixes := fmt.Sprint(r.Prefixes()); prefixes != test.prefixes {
+			t.Errorf("%s-%s prefixes are %s, want %s", test.from, test.to, prefixes, test.prefixes)
+		}
+	}
+}
+
+func TestScopeQuery(t *testing.T) {
+	scope := ParseScope("10.0.0.1-3 10.0.1.0/24 2001:db8::1")
+
+	if count := scope.Count().String(); count != "260" {
+		t.Errorf("the scope counts %s addresses, want 260", count)
+	}
+	// ipv6 is quoted since shodan reads a colon as the end of the filter name
+	if query := scope.Query(); query != `net:10.0.0.1,10.0.0.2/31,10.0.1.0/24,"2001:db8::1"` {
+		t.Errorf("the scope query is %q", query)
+	}
+	if !scope.Contains(netip.MustParseAddr("::ffff:10.0.0.2")) || scope.Contains(netip.MustParseAddr("10.0.0.4")) {
+		t.Error("the scope contains the wrong addresses")
+	}
+	if ParseScope("").Query() != "" {
+		t.Error("an empty scope gave a query")
+	}
+}
@@ -0,0 +1,200 @@
+package alerts
+
+import (
+	"sort"
+	"strings"
+	"time"
+)
+
+// ServiceBanner is one entry of the data list returned by /shodan/host
+type ServiceBanner struct {
+	Port      int             `json:"port,omitempty"`
+	Transport string          `json:"transport,omitempty"`
+	Product   string          `json:"product,omitempty"`
+	Version   string          `json:"version,omitempty"`
+	Hostnames []string        `json:"hostnames,omitempty"`
+	Domains   []string        `json:"domains,omitempty"`
+	Os        string          `json:"os,omitempty"`
+	Org       string          `json:"org,omitempty"`
+	Isp       string          `json:"isp,omitempty"`
+	Asn       string          `json:"asn,omitempty"`
+	Tags      []string        `json:"tags,omitempty"`
+	Cpe       []string        `json:"cpe23,omitempty"`
+	Vulns     map[string]Vuln `json:"vulns,omitempty"`
+	Timestamp string          `json:"timestamp,omitempty"`
+	// the raw response, for http it holds the status line and headers
+	Data string `json:"data,omitempty"`
+	Http *struct {
+		Title    string `json:"title,omitempty"`
+		Server   string `json:"server,omitempty"`
+		Status   int    `json:"status,omitempty"`
+		Location string `json:"location,omitempty"`
+		Html     string `json:"html,omitempty"`
+	} `json:"http,omitempty"`
+	Ssl *struct {
+		Cert struct {
+			Subject struct {
+				Cn string `json:"CN,omitempty"`
+			} `json:"subject"`
+			Issuer struct {
+				Cn string `json:"CN,omitempty"`
+			} `json:"issuer"`
+			Expires string `json:"expires,omitempty"`
+			Expired bool   `json:"expired,omitempty"`
+		} `json:"cert"`
+		Versions []string `json:"versions,omitempty"`
+	} `json:"ssl,omitempty"`
+	Shodan struct {
+		Module string `json:"module,omitempty"`
+	} `json:"_shodan"`
+}
+
+// Service is what was found listening on a port of an event
+type Service struct {
+	Port      int
+	Transport string
+	Module    string
+	Product   string
+	Version   string
+	Hostnames []string
+	Domains   []string
+	Os        string
+	Org       string
+	Isp       string
+	Asn       string
+	Tags      []string
+	Cpe       []string
+	Cpes      []Cpe
+	Timestamp time.Time
+
+	HttpTitle  string
+	HttpServer string
+	HttpStatus int
+
+	SslSubject  string
+	SslIssuer   string
+	SslExpires  string
+	SslExpired  bool
+	SslVersions []string
+
+	// set when the service runs software past its end of support
+	Eol *EolMatch
+	// set when the service serves a login page
+	Login *LoginPage
+}
+
+func NewService(banner ServiceBanner) Service {
+	timestamp, _ := time.Parse(shodanTimeLayout, banner.Timestamp)
+
+	service := Service{
+		Port:      banner.Port,
+		Transport: banner.Transport,
+		Module:    banner.Shodan.Module,
+		Product:   banner.Product,
+		Version:   banner.Version,
+		Hostnames: banner.Hostnames,
+		Domains:   banner.Domains,
+		Os:        banner.Os,
+		Org:       banner.Org,
+		Isp:       banner.Isp,
+		Asn:       banner.Asn,
+		Tags:      banner.Tags,
+		Cpe:       banner.Cpe,
+		Cpes:      ParseCpes(banner.Cpe),
+		Timestamp: timestamp,
+		Login:     findLoginPage(banner),
+	}
+
+	if banner.Http != nil {
+		service.HttpTitle = banner.Http.Title
+		service.HttpServer = banner.Http.Server
+		service.HttpStatus = banner.Http.Status
+	}
+
+	if banner.Ssl != nil {
+		service.SslSubject = banner.Ssl.Cert.Subject.Cn
+		service.SslIssuer = banner.Ssl.Cert.Issuer.Cn
+		service.SslExpires = banner.Ssl.Cert.Expires
+		service.SslExpired = banner.Ssl.Cert.Expired
+		service.SslVersions = banner.Ssl.Versions
+	}
+
+	return service
+}
+
+// the product and version, falling back to the http server header and then the shodan module
+func (s Service) Software() string {
+	software := strings.TrimSpace(s.Product + " " + s.Version)
+	if software == "" {
+		software = s.HttpServer
+	}
+	if software == "" {
+		software = s.Module
+	}
+
+	return software
+}
+
+// the name the service is most likely reached by
+func (s Service) Hostname() string {
+	if len(s.Hostnames) > 0 {
+		return s.Hostnames[0]
+	}
+	if s.SslSubject != "" && !strings.HasPrefix(s.SslSubject, "*") {
+		return s.SslSubject
+	}
+
+	return ""
+}
+
+// one line describing the service, like "OpenSSH 8.2p1 (tcp) - host.example.com"
+func (s Service) Label() string {
+	label := s.Software()
+	if s.Transport != "" && label != "" {
+		label += " (" + s.Transport + ")"
+	}
+	if hostname := s.Hostname(); hostname != "" {
+		if label != "" {
+			label += " - "
+		}
+		label += hostname
+	}
+
+	return label
+}
+
+func parseServices(banner Banner) map[int]Service {
+	services := make(map[int]Service)
+	for _, d := range banner.Data {
+		service := NewService(d)
+		service.Eol = findEol(service, time.Now())
+
+		// shodan can list a port twice, once per transport, keep the one that says the most
+		existing, ok := services[d.Port]
+		if ok && existing.Software() != "" && service.Software() == "" {
+			continue
+		}
+		services[d.Port] = service
+	}
+	return services
+}
+
+// the service label for a port, empty when nothing is known about it
+func (e *Event) ServiceLabel(port int) string {
+	service, ok := e.Services()[port]
+	if !ok {
+		return ""
+	}
+
+	return service.Label()
+}
+
+func sortedPorts(services map[int]Service) []int {
+	ports := []int{}
+	for port := range services {
+		ports = append(ports, port)
+	}
+	sort.Ints(ports)
+
+	return ports
+}
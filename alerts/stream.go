@@ -0,0 +1,147 @@
+package alerts
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/netip"
+	"strconv"
+	"time"
+)
+
+// shodan timestamps have no zone and are in utc
+const shodanTimeLayout = "2006-01-02T15:04:05.999999"
+
+// the parts of a streamed banner needed to build an event
+type StreamBanner struct {
+	Ip        string `json:"ip_str"`
+	Port      int    `json:"port"`
+	Timestamp string `json:"timestamp"`
+	Shodan    struct {
+		Alert struct {
+			Id      string `json:"id"`
+			Name    string `json:"name"`
+			Trigger string `json:"trigger"`
+		} `json:"alert"`
+	} `json:"_shodan"`
+}
+
+func NewEventFromStream(banner StreamBanner) (*Event, error) {
+	addr, err := netip.ParseAddr(banner.Ip)
+	if err != nil {
+		return nil, fmt.Errorf("invalid ip %q", banner.Ip)
+	}
+	ip := addr.String()
+
+	timestamp, err := time.Parse(shodanTimeLayout, banner.Timestamp)
+	if err != nil {
+		timestamp = time.Now().UTC()
+	}
+
+	trigger := banner.Shodan.Alert.Trigger
+	if trigger == "" {
+		trigger = "stream"
+	}
+
+	return &Event{
+		Ip:          ip,
+		Trigger:     trigger,
+		TriggerPort: banner.Port,
+		HostLink:    "https://www.shodan.io/host/" + ip,
+		Desc:        "Streamed banner on port " + strconv.Itoa(banner.Port),
+		Timestamp:   timestamp,
+		alertId:     banner.Shodan.Alert.Id,
+		name:        banner.Shodan.Alert.Name,
+		ports:       make(map[int][]Cve),
+		services:    make(map[int]Service),
+		done:        make(chan struct{}),
+	}, nil
+}
+
+// ErrStreamEnded is returned by streams that have nothing more to send, like a recorded
+// one, so they are not reconnected
+var ErrStreamEnded = errors.New("stream ended")
+
+// ConsumeStream reads the alert stream until the context ends, reconnecting whenever
+// it drops. Each new banner is de-duplicated through the event cache before onEvent sees it
+func ConsumeStream(ctx context.Context, client ShodanClient, onEvent func(*Event)) {
+	cache := NewEventCache()
+
+	for attempt := 0; ctx.Err() == nil; attempt++ {
+		read, err := readStream(ctx, client, cache, onEvent)
+		if errors.Is(err, ErrStreamEnded) {
+			return
+		}
+		if err != nil && ctx.Err() == nil {
+			fmt.Println("Error: alert stream", err.Error())
+		}
+
+		// a stream that delivered banners was healthy, so start the backoff over
+		if read > 0 {
+			attempt = 0
+		}
+
+		select {
+		case <-ctx.Done():
+		case <-time.After(backoff(attempt, "")):
+		}
+	}
+}
+
+func readStream(ctx context.Context, client ShodanClient, cache *EventCache, onEvent func(*Event)) (int, error) {
+	stream, err := client.Stream(ctx)
+	if err != nil {
+		return 0, err
+	}
+	defer stream.Close()
+
+	// banners that name no alert are matched against the alert list, fetched when first needed
+	var index *AlertIndex
+	var indexErr error
+
+	scanner := bufio.NewScanner(stream)
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+	read := 0
+
+	for scanner.Scan() {
+		line := scanner.Bytes()
+		// shodan sends empty lines to keep the connection open
+		if len(line) == 0 {
+			continue
+		}
+
+		banner := StreamBanner{}
+		if err := json.Unmarshal(line, &banner); err != nil {
+			fmt.Println("Error: alert stream banner", err.Error())
+			continue
+		}
+
+		event, err := NewEventFromStream(banner)
+		if err != nil {
+			fmt.Println("Error: alert stream banner", err.Error())
+			continue
+		}
+		read++
+
+		if cache.HasEventBeenSeen(event) {
+			continue
+		}
+		cache.InsertEvent(event)
+
+		if event.AlertId() == "" {
+			if index == nil && indexErr == nil {
+				index, indexErr = DownloadAlerts(client)
+			}
+			event.matchAlert(index, indexErr)
+		}
+
+		onEvent(event)
+	}
+
+	if err := scanner.Err(); err != nil {
+		return read, err
+	}
+	return read, errors.New("stream closed")
+}
@@ -0,0 +1,151 @@
+package alerts
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+const testStream = `{"ip_str": "198.51.100.20", "port": 443, "timestamp": "2026-10-12T10:00:00.000000", "_shodan": {"alert": {"id": "ACME", "name": "Acme", "trigger": "ssl_expired"}}}
+
+{"ip_str": "198.51.100.21", "port": 22, "timestamp": "2026-10-12T10:01:00.000000"}
+not a banner
+{"ip_str": "198.51.100.20", "port": 443, "timestamp": "2026-10-12T10:00:00.000000", "_shodan": {"alert": {"id": "ACME", "name": "Acme", "trigger": "ssl_expired"}}}
+{"ip_str": "not an ip", "port": 80}
+`
+
+// runs ConsumeStream until it returns or the timeout passes, giving the events it saw
+func consume(t *testing.T, client ShodanClient, timeout time.Duration) ([]*Event, bool) {
+	NewEventCache().ClearTable()
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	events := []*Event{}
+	ConsumeStream(ctx, client, func(e *Event) {
+		events = append(events, e)
+	})
+	return events, ctx.Err() == nil
+}
+
+func checkStreamEvents(t *testing.T, events []*Event) {
+	t.Helper()
+
+	// the repeated banner is dropped by the cache, the broken lines are skipped
+	if len(events) != 2 {
+		t.Fatalf("got %d events, want 2", len(events))
+	}
+
+	tests := []struct {
+		ip      string
+		port    int
+		trigger string
+		alertId string
+	}{
+		{"198.51.100.20", 443, "ssl_expired", "ACME"},
+		// banners naming no alert are matched against the alert list
+		{"198.51.100.21", 22, "stream", "ACME"},
+	}
+	for i, test := range tests {
+		e := events[i]
+		if e.Ip != test.ip || e.TriggerPort != test.port || e.Trigger != test.trigger || e.AlertId() != test.alertId {
+			t.Errorf("event %d is %s:%d %q from %q, want %s:%d %q from %q", i, e.Ip, e.TriggerPort, e.Trigger, e.AlertId(), test.ip, test.port, test.trigger, test.alertId)
+		}
+	}
+}
+
+// serves the alert stream over three connections: the first drops after one banner,
+// the second is refused and the third sends the rest and stays open
+func newStreamServer(t *testing.T) (*HttpClient, *atomic.Int32) {
+	t.Helper()
+
+	lines := strings.SplitAfterN(testStream, "\n", 2)
+	connections := &atomic.Int32{}
+	client := newShodanServer(t, map[string]http.HandlerFunc{
+		"/shodan/alert": func(w http.ResponseWriter, r *http.Request) {
+			n := connections.Add(1)
+			if key := r.URL.Query().Get("key"); key != "test-key" {
+				t.Errorf("stream opened with key %q, want test-key", key)
+			}
+
+			switch n {
+			case 1:
+				fmt.Fprint(w, lines[0])
+				w.(http.Flusher).Flush()
+				// drops the connection without ending the response
+				panic(http.ErrAbortHandler)
+			case 2:
+				http.Error(w, "unauthorized", http.StatusUnauthorized)
+			case 3:
+				fmt.Fprint(w, lines[1])
+				w.(http.Flusher).Flush()
+				<-r.Context().Done()
+			default:
+				t.Errorf("stream opened %d times, want 3", n)
+			}
+		},
+		"/shodan/alert/info": jsonBody(testAlerts),
+	})
+
+	return client, connections
+}
+
+func TestConsumeStream(t *testing.T) {
+	client, connections := newStreamServer(t)
+	NewEventCache().ClearTable()
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	// a live stream is reconnected whenever it drops or is refused, so it runs until
+	// the context ends
+	events := []*Event{}
+	ConsumeStream(ctx, client, func(e *Event) {
+		events = append(events, e)
+		if len(events) == 2 {
+			cancel()
+		}
+	})
+
+	if connections.Load() != 3 {
+		t.Errorf("the stream was opened %d times, want 3", connections.Load())
+	}
+	checkStreamEvents(t, events)
+}
+
+func TestStreamStatus(t *testing.T) {
+	client := newShodanServer(t, map[string]http.HandlerFunc{
+		"/shodan/alert": func(w http.ResponseWriter, r *http.Request) {
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+		},
+	})
+
+	_, err := client.Stream(context.Background())
+	statusErr := &StatusError{}
+	if !errors.As(err, &statusErr) || statusErr.Code != http.StatusUnauthorized {
+		t.Errorf("got error %v, want the 401", err)
+	}
+}
+
+func TestConsumeReplayedStream(t *testing.T) {
+	dir := t.TempDir()
+	os.WriteFile(filepath.Join(dir, streamFile()), []byte(testStream), 0644)
+	os.WriteFile(filepath.Join(dir, alertsFile()), []byte(testAlerts), 0644)
+
+	// a recorded stream is read once and then ends
+	events, returned := consume(t, NewReplayClient(dir), 10*time.Second)
+	if !returned {
+		t.Fatal("ConsumeStream kept replaying the recorded stream")
+	}
+	checkStreamEvents(t, events)
+
+	// a session recorded without the stream has nothing to replay
+	if _, returned := consume(t, NewReplayClient(t.TempDir()), 10*time.Second); !returned {
+		t.Error("ConsumeStream kept retrying a missing recorded stream")
+	}
+}
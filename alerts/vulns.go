@@ -0,0 +1,95 @@
+package alerts
+
+import (
+	"database/sql"
+	"sync"
+	"time"
+
+	_ "modernc.org/sqlite"
+)
+
+// VulnStore holds the vulnerability catalogs imported from disk
+type VulnStore struct {
+	db *sql.DB
+}
+
+func NewVulnStore() *VulnStore {
+	db, err := sql.Open("sqlite", "./resources/vulns.db")
+	if err != nil {
+		panic("Missing Resoruces")
+	}
+
+	store := &VulnStore{
+		db: db,
+	}
+
+	store.ensureTables()
+	return store
+}
+
+func (v *VulnStore) ensureTables() {
+	v.ensureKevTables()
+	v.ensureEpssTables()
+}
+
+// the catalogs are read into memory once since every cve of every host is looked up in them
+var (
+	catalogMu     sync.Mutex
+	catalogLoaded bool
+	kevCatalog    map[string]KevEntry
+	epssCatalog   map[string]EpssScore
+	epssModel     EpssModel
+)
+
+func loadCatalogs() {
+	catalogMu.Lock()
+	defer catalogMu.Unlock()
+
+	if catalogLoaded {
+		return
+	}
+
+	store := NewVulnStore()
+	kevCatalog = store.kevEntries()
+	epssCatalog, epssModel = store.epssScores()
+	catalogLoaded = true
+}
+
+func reloadCatalogs() {
+	catalogMu.Lock()
+	catalogLoaded = false
+	catalogMu.Unlock()
+}
+
+// enrich fills in the kev details and epss scores from the imported catalogs. The kev
+// catalog decides if a cve is known exploited over the flag shodan sent, and the imported
+// epss score is used when its model ran after shodan scanned the service
+func (c *Cve) enrich(scanned time.Time) {
+	loadCatalogs()
+
+	catalogMu.Lock()
+	entry, ok := kevCatalog[c.Name]
+	epss, scored := epssCatalog[c.Name]
+	model := epssModel
+	catalogMu.Unlock()
+
+	if ok {
+		c.Kev = true
+		c.KevDateAdded = entry.DateAdded
+		c.KevDueDate = entry.DueDate
+		c.KevAction = entry.RequiredAction
+		c.KevRansomware = entry.KnownRansomware()
+	}
+
+	if !scanned.IsZero() {
+		c.EpssDate = scanned.Format(time.DateOnly)
+	}
+	if scored && (scanned.IsZero() || model.Date.After(scanned)) {
+		c.Epss = epss.Score
+		c.EpssPercentile = epss.Percentile
+		c.EpssDate = model.Date.Format(time.DateOnly)
+		c.EpssModel = model.String()
+	}
+
+	c.rank()
+}
@@ -4,7 +4,6 @@ import (
 	"fmt"
 	"os"
 	"strconv"
-	"time"
 
 	"github.com/eagledb14/form-scanner/alerts"
 	createform "github.com/eagledb14/form-scanner/create-form"
@@ -12,26 +11,30 @@ import (
 	"github.com/eagledb14/form-scanner/types"
 )
 
-func autoCreateEventFiles() {
+func autoCreateEventFiles(client alerts.ShodanClient) {
 	fmt.Println("Generating...")
 	os.MkdirAll("generated-forms", 0755)
 
-	events := alerts.DownloadRss()
-	for _, e := range events {
-		time.Sleep(time.Duration(3 * time.Second))
-		go func(e *alerts.Event) {
-			e.Load()
-		}(e)
+	events, quarantined := alerts.DownloadRss(client)
+	for _, q := range quarantined {
+		fmt.Println("Quarantined:", q.Item.Title, "-", q.Reason)
 	}
+
+	alerts.SharedLimiter().ForEach(len(events), func(i int) {
+		events[i].Load(client)
+	})
 	forms := []createform.OpenPort{}
 
 	for i, e := range events {
+		triggerForm := types.FormForTrigger(e.Trigger)
+		summary, body := templates.FormText(triggerForm, e.Name(), []*alerts.Event{e})
+
 		form := createform.OpenPort{
-			OrgName:    e.Name,
+			OrgName:    e.Name(),
 			FormNumber: strconv.Itoa(i),
-			Threat:     "T1133 External Remote Services",
-			Summary:    templates.OpenPortSummary(e.Name, []*alerts.Event{e}),
-			Body:       templates.OpenPortBody(e.Name, []*alerts.Event{e}),
+			Threat:     triggerForm.Threat,
+			Summary:    summary,
+			Body:       body,
 			Tlp:        true,
 			Events:     []*alerts.Event{e},
 		}
@@ -40,10 +43,16 @@ func autoCreateEventFiles() {
 
 	state := &types.State{}
 	for i, form := range forms {
+		// nobody reviews these files before they go out, so skip any event missing data
+		if warning := alerts.IncompleteWarning(form.Events); warning != "" {
+			fmt.Println("Skipping:", warning)
+			continue
+		}
+
 		md := form.CreateMarkdown(state)
-		html := createform.CreateHeaderHtml(md, events[i].Name, true)
+		html := createform.CreateHeaderHtml(md, events[i].Name(), true)
 
-		fileName := "./generated-forms/" + events[i].Name + "-" + state.AlertId + ".html"
+		fileName := "./generated-forms/" + events[i].Name() + "-" + state.AlertId + ".html"
 		fmt.Println(fileName)
 
 		file, _ := os.Create(fileName)
@@ -2,14 +2,15 @@ package createform
 
 import (
 	"encoding/csv"
+	"strconv"
 	"strings"
 
 	"github.com/eagledb14/form-scanner/alerts"
 )
 
 
-func CreateCsv(query string) string {
-	net :=	alerts.DownloadMatches(query)
+func CreateCsv(client alerts.ShodanClient, query string) (string, string) {
+	net :=	alerts.DownloadMatches(client, query)
 	data := [][]string{
 		{"asn", "ip", "port", "timestamp", "domains", "data", "hostnames", "isp", "org", "os", "country", "country code", "region code", "city", "product"},
 	}
@@ -18,7 +19,7 @@ func CreateCsv(query string) string {
 		row := []string{
 			match.Asn,
 			match.Ip,
-			match.Port,
+			strconv.Itoa(match.Port),
 			match.Timestamp,
 			strings.Join(match.Domains, " "),
 			strings.Join(match.Hostnames, " "),
@@ -43,5 +44,5 @@ func CreateCsv(query string) string {
 
 	writer.Flush()
 
-	return csvString.String()
+	return csvString.String(), net.Warning()
 }
@@ -0,0 +1,35 @@
+package createform
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// runs every test in a scratch directory with its own resources, so the vulnerability
+// database the tests import into is never the real one
+func TestMain(m *testing.M) {
+	dir, err := os.MkdirTemp("", "createform-test")
+	if err != nil {
+		panic(err)
+	}
+
+	resources := filepath.Join(dir, "resources")
+	os.MkdirAll(resources, 0755)
+	for _, name := range []string{"eol.json", "risky_ports.json"} {
+		body, err := os.ReadFile(filepath.Join("..", "resources", name))
+		if err != nil {
+			panic(err)
+		}
+		os.WriteFile(filepath.Join(resources, name), body, 0644)
+	}
+
+	wd, _ := os.Getwd()
+	os.Chdir(dir)
+
+	code := m.Run()
+
+	os.Chdir(wd)
+	os.RemoveAll(dir)
+	os.Exit(code)
+}
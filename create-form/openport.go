@@ -86,13 +86,22 @@ func getEventsString(events []*alerts.Event) string {
 	}
 
 	const page = `
-{{range .Events}}
-### [{{.Ip}}]({{.HostLink}})
+{{range $event := .Events}}
+### [{{.Ip}}]({{.HostLink}}){{if .Hostnames}} ({{range $i, $host := .Hostnames}}{{if $i}}, {{end}}{{$host}}{{end}}){{end}}
 {{range $key, $value := .Ports}}
-{{$key}}
-{{range $value}}
-- [{{.Name}}](https://www.cve.org/CVERecord?id={{.Name}}) Priority: {{.Rank}}
-	- {{.Summary}}
+{{$key}}{{with $event.ServiceLabel $key}} - {{.}}{{end}}
+{{with $event.Eol $key}}
+**End of life:** {{.Product}}{{with .Version}} {{.}}{{end}} reached end of support on {{.DateString}}
+{{end}}{{with $event.Login $key}}
+**Login page:** {{.Product}} at {{.Url $event.Ip $key}}
+{{end}}{{with $event.PortRisk $key}}
+**{{.Service}} exposed:** {{.Risk}}
+- **ATT&CK Technique:** {{.Technique}}
+- **Remediation:** {{.Remediation}}
+{{end}}{{range $value}}
+- [{{.Name}}](https://www.cve.org/CVERecord?id={{.Name}}) {{.Priority}}
+	- {{.Summary}}{{if .KevAction}}
+	- **CISA KEV Required Action:** {{.KevAction}} **Due:** {{.KevDueDate}}{{if .KevRansomware}} **Known ransomware campaign use**{{end}}{{end}}
 {{end}}
 {{end}}
 {{end}}
@@ -111,30 +120,35 @@ func getSourcesString(events []*alerts.Event) string {
 	return builder.String()
 }
 
+// the priority key is built from the active policy so it always describes the ranking used
 func cvePriorityKey() string {
-	return `
-## CVE Priority Key
-[EPSS User Guide (first.org)](https://www.first.org/epss/user-guide)
-
-[CVSS, EPSS, and CISA's Known Exploited Vulnerabilities (Github.com)](https://github.com/eagledb14/CVE_Prioritizer/tree/main?tab=readme-ov-file#our-approach)
-
-<small>Priority severity is ranked 0 (highest) to 4 (lowest)</small>
-
-### Priority 0
-CISA (Cybersecurity and Infrastructure Security Agency) has declared this vulnerability as being a known exploited vulnerability. Should be taken as highest priority and addressed immediately.
-
-### Priority 1
-The most critical kinds of vulnerabilities which are more likely to be exploited, and could fully compromise the information system. They should be patched first.
-
-### Priority 2
-May severely impact the system, are much less likely to be exploited, relative to others, but should still be watched in the event that the threat landscape changes.
-
-### Priority 3
-May be more likely to be exploited, but, on their own, would not critically impact the information system.
+	return priorityKey(alerts.ActivePolicy())
+}
 
-### Priority 4
-Vulnerabilities may be more likely to be exploited on their own, but would not critically impact the system.
-`
+func priorityKey(policy alerts.PriorityPolicy) string {
+	const page = `
+## CVE Priority Key
+{{range .References}}
+[{{.Title}}]({{.Url}})
+{{end}}
+<small>Priority severity is ranked {{.Highest}} (highest) to {{.Lowest}} (lowest)</small>
+{{if eq .Type "ssvc"}}
+Vulnerabilities are prioritized with a decision tree. Exploitation is active when the vulnerability is in the CISA Known Exploited Vulnerabilities catalog, poc when its EPSS is at least {{.Ssvc.PocEpss}}, and none otherwise. It is automatable when its CVSS vector is reachable over the network without privileges or user interaction. Technical impact is total when its CVSS is at least {{printf "%.1f" .Ssvc.TotalImpactCvss}}, and partial otherwise.
+
+| Exploitation | Automatable | Technical Impact | Priority |
+|---|---|---|---|{{range .Ssvc.Rules}}
+| {{or .Exploitation "any"}} | {{or .Automatable "any"}} | {{or .TechnicalImpact "any"}} | {{.Decision}} |{{end}}
+{{range .Levels}}
+### {{.Label}}
+{{.Description}}
+{{end}}{{else}}{{range .Levels}}
+### {{.Label}}
+{{.Description}}
+
+<small>{{.Criteria}}</small>
+{{end}}{{end}}`
+
+	return templates.ExecuteText("priorityKey", page, policy)
 }
 
 func mitigations() string {
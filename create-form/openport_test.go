@@ -0,0 +1,53 @@
+package createform
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/eagledb14/form-scanner/alerts"
+)
+
+// answers every host lookup with the same banner, every other call panics on the nil client
+type hostClient struct {
+	alerts.ShodanClient
+	host string
+}
+
+func (h hostClient) Host(ip string) ([]byte, error) {
+	return []byte(h.host), nil
+}
+
+// the kev details of each cve are printed under it in the open port report
+func TestOpenPortKev(t *testing.T) {
+	catalog := filepath.Join(t.TempDir(), "kev.json")
+	os.WriteFile(catalog, []byte(`{"vulnerabilities": [
+		{"cveID": "CVE-2099-1001", "dateAdded": "2026-09-01", "dueDate": "2026-09-22", "requiredAction": "Apply mitigations per vendor instructions.", "knownRansomwareCampaignUse": "Known"},
+		{"cveID": "CVE-2099-1002", "dateAdded": "2026-09-15", "dueDate": "2026-10-06", "requiredAction": "Discontinue use of the product.", "knownRansomwareCampaignUse": "Unknown"}
+	]}`), 0644)
+	if _, err := alerts.NewVulnStore().ImportKev(catalog); err != nil {
+		t.Fatal(err)
+	}
+
+	event := alerts.NewEventFromIp("192.0.2.1")
+	event.Load(hostClient{host: `{"ports": [443], "data": [{"port": 443, "transport": "tcp", "product": "nginx", "vulns": {
+		"CVE-2099-1001": {"cvss": 9.8, "summary": "First"},
+		"CVE-2099-1002": {"cvss": 7.5, "summary": "Second"},
+		"CVE-2099-1003": {"cvss": 5.0, "summary": "Third"}
+	}}]}`})
+
+	markdown := getEventsString([]*alerts.Event{event})
+
+	for _, want := range []string{
+		"**CISA KEV Required Action:** Apply mitigations per vendor instructions. **Due:** 2026-09-22 **Known ransomware campaign use**",
+		"**CISA KEV Required Action:** Discontinue use of the product. **Due:** 2026-10-06\n",
+	} {
+		if !strings.Contains(markdown, want) {
+			t.Errorf("report is missing %q:\n%s", want, markdown)
+		}
+	}
+	if count := strings.Count(markdown, "CISA KEV"); count != 2 {
+		t.Errorf("report lists kev details %d times, want twice:\n%s", count, markdown)
+	}
+}
@@ -3,15 +3,31 @@ package createform
 import (
 	"html/template"
 	"sort"
+	"strings"
 
 	"github.com/eagledb14/form-scanner/alerts"
 	"github.com/eagledb14/form-scanner/templates"
 )
 
+// OsintQuery is a shodan search the report's results came from
+type OsintQuery struct {
+	Purpose string
+	Query   string
+	// Shodan, or the name of the export file that was searched instead
+	Source string
+	Hosts  int
+}
+
 type Osint struct {
 	Name            string
 	InScope         []string
 	OutScope        []string
+	InScopeCount    string
+	OutScopeCount   string
+	InScopeHosts    []alerts.HostMapping
+	OutScopeHosts   []alerts.HostMapping
+	SearchIps       []string
+	Queries         []OsintQuery
 	Events          []*alerts.Event
 	Url             string
 	UrlIps		[]*alerts.Event
@@ -26,10 +42,18 @@ func (o *Osint) CreateMarkdown() string {
 	for _, event := range o.Events {
 		event.FilterCves()
 	}
+	epssModels, shodanEpss := epssSources(o.Events)
+
 	data := struct {
 		Name            string
 		InScopeIps      []string
 		OutScopeIps     []string
+		InScopeCount    string
+		OutScopeCount   string
+		InScopeHosts    []alerts.HostMapping
+		OutScopeHosts   []alerts.HostMapping
+		SearchIps       []string
+		Queries         []OsintQuery
 		Events          []*alerts.Event
 		CveDisplay      string
 		AssetSeverity   string
@@ -37,6 +61,9 @@ func (o *Osint) CreateMarkdown() string {
 		WebsiteSeverity string
 
 		MaxPriority []alerts.Cve
+		Policy      alerts.PriorityPolicy
+		EpssModels  []string
+		ShodanEpss  bool
 
 		Urls             string
 		VulnerableUrls int
@@ -52,6 +79,12 @@ func (o *Osint) CreateMarkdown() string {
 		Name:        o.Name,
 		InScopeIps:  o.InScope,
 		OutScopeIps: o.OutScope,
+		InScopeCount:  o.InScopeCount,
+		OutScopeCount: o.OutScopeCount,
+		InScopeHosts:  o.InScopeHosts,
+		OutScopeHosts: o.OutScopeHosts,
+		SearchIps:     o.SearchIps,
+		Queries:       o.Queries,
 		Events:      alerts.FilterEvents(o.Events),
 		CveDisplay:  displayCves(o.Events),
 
@@ -60,6 +93,9 @@ func (o *Osint) CreateMarkdown() string {
 		WebsiteSeverity: o.WebsiteSeverity,
 
 		MaxPriority: filterMaxPriority(o.Events),
+		Policy:      alerts.ActivePolicy(),
+		EpssModels:  epssModels,
+		ShodanEpss:  shodanEpss,
 
 		Urls:             o.Url,
 		VulnerableUrls: o.VulnerableUrls,
@@ -77,6 +113,11 @@ func (o *Osint) CreateMarkdown() string {
 		"add": func(a, b int) int {
 			return a + b
 		},
+		"join": strings.Join,
+		// pipes would end the table cell early
+		"cell": func(s string) string {
+			return strings.ReplaceAll(s, "|", "\\|")
+		},
 	}
 
 	const page = `
@@ -98,27 +139,57 @@ The initial phase of the passive review is External Asset Discovery. In this sec
 The IPs provided below are associated with {{.Name}}’s domain on open-source DNS record websites, and are provided solely for {{.Name}}’s awareness.
 
 ### 1.1 External IPs Provided Within Scope
-
+{{if .InScopeIps}}
+{{.InScopeCount}} address(es) were provided within scope.
+{{end}}
 {{range $index, $val := .InScopeIps}}
 {{add $index 1}}. {{$val}}
 {{end}}
+{{if .InScopeHosts}}
+The hostnames provided within scope resolved to the following IPs.
 
+| Hostname | IPs |
+|---|---|{{range .InScopeHosts}}
+| {{.Host}} | {{join .Ips ", "}} |{{end}}
+{{end}}
 ### 1.2 External IPs Indentified Outside Of Scope
-
+{{if .OutScopeIps}}
+{{.OutScopeCount}} address(es) were identified outside of scope.
+{{end}}
 {{range $index, $val := .OutScopeIps}}
 {{add $index 1}}. {{$val}}
 {{end}}
-
+{{if .OutScopeHosts}}
+The hostnames identified outside of scope resolved to the following IPs.
+
+| Hostname | IPs |
+|---|---|{{range .OutScopeHosts}}
+| {{.Host}} | {{join .Ips ", "}} |{{end}}
+{{end}}{{if .SearchIps}}
+{{len .SearchIps}} more host(s) were identified outside of scope by searching Shodan.
+{{range $index, $val := .SearchIps}}
+{{add $index 1}}. {{$val}}
+{{end}}{{end}}
 
 ---
 
 ## 2 Identifying Vulnerable External Devices with Shodan
 
 Shodan.io is an open-source search engine that is designed to gather information about internet-connected devices and systems. The NCNG searched Shodan’s public database for any assets owned by {{.Name}} using the CIDR Blocks or IP addresses provided within scope and identified through asset discovery. 
+{{if .Queries}}
+The results in this report were produced by the following Shodan searches.
 
+| Purpose | Query | Source | Hosts |
+|---|---|---|---|{{range .Queries}}
+| {{.Purpose}} | {{cell .Query}} | {{cell .Source}} | {{.Hosts}} |{{end}}
+{{end}}
 ### 2.1 Scoring 
-The table below uses the Exploit Prediction Scoring System (EPSS) and Common Vulnerability Scoring System (CVSS) to measure vulnerabilities. EPSS produces prediction scores between 0 and 1 (0 and 100%) where higher scores suggest probability of exploit and CVSS rates the severity of a vulnerability. Vulnerabilities are prioritized in order from 0 to 4, 0 being the most severe and 4 being the least severe.
-
+The table below uses the Exploit Prediction Scoring System (EPSS) and Common Vulnerability Scoring System (CVSS) to measure vulnerabilities. EPSS produces prediction scores between 0 and 1 (0 and 100%) where higher scores suggest probability of exploit and CVSS rates the severity of a vulnerability. Vulnerabilities are prioritized in order from {{.Policy.Highest}} to {{.Policy.Lowest}}, {{.Policy.Highest}} being the most severe and {{.Policy.Lowest}} being the least severe.
+{{if gt (len .EpssModels) 0}}
+EPSS scores were taken from the FIRST EPSS model {{range $index, $val := .EpssModels}}{{if gt $index 0}}, {{end}}{{$val}}{{end}}{{if .ShodanEpss}}, scores for vulnerabilities scanned after that model ran are the ones recorded by Shodan on the scan date{{end}}.
+{{else if .ShodanEpss}}
+EPSS scores are the ones recorded by Shodan on the date each asset was scanned, shown next to each score.
+{{end}}
 ### 2.2 Results/Findings
 Within the list of IP addresses above, {{len .Events}} vulnerable asset(s) are indexed by Shodan.
 
@@ -128,10 +199,11 @@ Within the list of IP addresses above, {{len .Events}} vulnerable asset(s) are i
 ### 2.{{add (len .Events) 3}} Impact to Agency (External Asset Vulnerabilities)
 
 {{if gt (len .MaxPriority) 0}}
-It is essential to recognize that external assets, which {{.Name}} may not be fully aware of, could pose significant risks. These risks might encompass unpatched software, misconfigurations, exposed sensitive data, or critical vulnerabilities, such as the {{len .MaxPriority}} number of Priority 1 CVEs expanded upon below. 
+It is essential to recognize that external assets, which {{.Name}} may not be fully aware of, could pose significant risks. These risks might encompass unpatched software, misconfigurations, exposed sensitive data, or critical vulnerabilities, such as the {{len .MaxPriority}} number of {{join .Policy.HighlightLabels " and "}} CVEs expanded upon below. 
 
 {{range .MaxPriority}}
-- **{{.Name}}** {{.Summary}}
+- **{{.Name}}** {{.Summary}}{{if .KevAction}}
+	- **CISA KEV Required Action:** {{.KevAction}} **Due:** {{.KevDueDate}}{{if .KevRansomware}} **Known ransomware campaign use**{{end}}{{end}}
 {{end}}
 
 Such vulnerabilities emphasize the importance of proactive asset discovery, patch management, and security measures to safeguard {{.Name}} from these vulnerabilities. 
@@ -206,11 +278,11 @@ The external IPs; {{range .Events}}“{{.Ip}}”, {{end}}are tagged with vulnera
 
 {{if eq (len .Events) 0}}{{else}}{{range $index, $val := .Events}}{{if gt (len .Ports) 0}}
 
-**2.{{add $index 3}} [{{$val.Ip}}]**
+**2.{{add $index 3}} [{{$val.Ip}}]{{if $val.Hostnames}} ({{range $i, $host := $val.Hostnames}}{{if $i}}, {{end}}{{$host}}{{end}}){{end}}**
 
-| CVE-ID | PRIORITY | EPSS | CVSS | VERSION | SEVERITY | CISA_KEV | VENDOR | PRODUCT |
-|---|---|---|---|---|---|---|---|---|{{range $key, $cve := $val.Ports}}{{range $cve}}
-| {{.Name}} | Priority {{.Rank}} | {{.Epss}} | {{.Cvss}} | {{.Version}} | {{.Severity}} | {{.Kev}} | {{.Vendor}} | {{.Product}} |{{end}}{{end}}{{end}}{{end}}
+| CVE-ID | PRIORITY | EPSS | EPSS_PERCENTILE | EPSS_DATE | CVSS | CVSS_VERSION | SEVERITY | CISA_KEV | VENDOR | PRODUCT | PRODUCT_VERSION |
+|---|---|---|---|---|---|---|---|---|---|---|---|{{range $key, $cve := $val.Ports}}{{range $cve}}
+| {{.Name}} | {{.Priority}} | {{.Epss}} | {{if .EpssModel}}{{.EpssPercentile}}{{end}} | {{.EpssDate}} | {{.Cvss}} | {{.Version}} | {{.Severity}} | {{.Kev}} | {{.Vendor}} | {{.Product}} | {{.ProductVersion}} |{{end}}{{end}}{{end}}{{end}}
 <br>
 {{end}}`
 
@@ -220,7 +292,7 @@ The external IPs; {{range .Events}}“{{.Ip}}”, {{end}}are tagged with vulnera
 func displayUrlCves(events []*alerts.Event, url string) string {
 	uniqueCve := make(map[string]alerts.Cve)
 	for _, e := range events {
-		for _, cve := range e.Ports {
+		for _, cve := range e.Ports() {
 			for _, c := range cve {
 				uniqueCve[c.Name] = c
 			}
@@ -247,7 +319,7 @@ func displayUrlCves(events []*alerts.Event, url string) string {
 	}
 	const page = `
 {{range .Cves}}
-- {{.Name}}: Priority {{.Rank}}
+- {{.Name}}: {{.Priority}}
 	- {{.Summary}}
 {{end}}
 `
@@ -285,11 +357,12 @@ func disclaimer(name string) string {
 
 func filterMaxPriority(events []*alerts.Event) []alerts.Cve {
 	maxCves := []alerts.Cve{}
+	policy := alerts.ActivePolicy()
 
 	for _, event := range events {
-		for _, cves := range event.Ports {
+		for _, cves := range event.Ports() {
 			for _, cve := range cves {
-				if cve.Rank < 2 {
+				if policy.Highlighted(cve.Rank) {
 					maxCves = append(maxCves, cve)
 				}
 			}
@@ -299,6 +372,29 @@ func filterMaxPriority(events []*alerts.Event) []alerts.Cve {
 	return maxCves
 }
 
+// the imported epss models used by the cves, and if any cve kept the score shodan sent
+func epssSources(events []*alerts.Event) ([]string, bool) {
+	models := []string{}
+	seen := make(map[string]bool)
+	shodan := false
+
+	for _, event := range events {
+		for _, cves := range event.Ports() {
+			for _, cve := range cves {
+				if cve.EpssModel == "" {
+					shodan = true
+				} else if !seen[cve.EpssModel] {
+					seen[cve.EpssModel] = true
+					models = append(models, cve.EpssModel)
+				}
+			}
+		}
+	}
+
+	sort.Strings(models)
+	return models, shodan
+}
+
 func countPassowords(creds []alerts.Credentials) int {
 	numPasswords := 0
 
@@ -11,12 +11,12 @@ type PortViewer struct {
 
 func (p *PortViewer) CreateMarkdown() string {
 	const page = `
-{{range .Events}}
-### {{.Ip}}
+{{range $event := .Events}}
+### {{.Ip}}{{if .Hostnames}} ({{range $i, $host := .Hostnames}}{{if $i}}, {{end}}{{$host}}{{end}}){{end}}
 {{range $key, $cve := .Ports}}
-{{$key}}
+{{$key}}{{with $event.ServiceLabel $key}} - {{.}}{{end}}{{with $event.Eol $key}} - End of life since {{.DateString}}{{end}}
 {{range $cve}}
-- {{.Name}} Priority: {{.Rank}}
+- {{.Name}} {{.Priority}}
 {{end}}
 {{end}}
 {{end}}
@@ -2,12 +2,15 @@ package main
 
 import (
 	"bufio"
+	"context"
 	"flag"
+	"fmt"
 	"net"
 	"os"
 	"strconv"
 	"strings"
 
+	"github.com/eagledb14/form-scanner/alerts"
 	"github.com/eagledb14/form-scanner/types"
 )
 
@@ -18,14 +21,43 @@ func main() {
 
 func run() {
 	checkResources()
+	// a broken priority policy or trigger mapping should stop the program before any report is built with it
+	alerts.ActivePolicy()
+	types.TriggerForms()
 
 	auto := flag.Bool("auto", false, "run in automatic mode")
+	record := flag.String("record", "", "save every shodan response to this directory")
+	replay := flag.String("replay", "", "serve shodan responses from a recorded directory instead of the api")
+	stream := flag.Bool("stream", false, "add events from the shodan alert stream as they arrive")
+	kev := flag.String("kev", "", "import the CISA known exploited vulnerabilities json file")
+	epss := flag.String("epss", "", "import the FIRST daily EPSS csv, gzipped or not")
 	flag.Parse()
 
+	if *kev != "" {
+		count, err := alerts.NewVulnStore().ImportKev(*kev)
+		if err != nil {
+			panic("error importing kev catalog: " + err.Error())
+		}
+		fmt.Println("Imported", count, "known exploited vulnerabilities")
+	}
+
+	if *epss != "" {
+		count, err := alerts.NewVulnStore().ImportEpss(*epss)
+		if err != nil {
+			panic("error importing epss scores: " + err.Error())
+		}
+		fmt.Println("Imported", count, "epss scores")
+	}
+
+	client := newClient(*record, *replay)
+
 	if *auto {
-		autoCreateEventFiles()
+		autoCreateEventFiles(client)
 	} else {
-		state := types.NewState()
+		state := types.NewState(client)
+		if *stream {
+			state.Stream(context.Background())
+		}
 		var port = ""
 
 if os.Getenv("DEV") == "true" {
@@ -38,6 +70,20 @@ if os.Getenv("DEV") == "true" {
 	}
 }
 
+func newClient(record string, replay string) alerts.ShodanClient {
+	if replay != "" {
+		return alerts.NewReplayClient(replay)
+	}
+
+	// the recorder sits outside the cache so cached hosts are recorded as well
+	var client alerts.ShodanClient = alerts.NewCachedClient(alerts.NewClientFromEnv())
+	if record != "" {
+		return alerts.NewRecordClient(client, record)
+	}
+
+	return client
+}
+
 func loadEnvVars() {
 	file, err := os.Open("./resources/key.env")
 	if err != nil {
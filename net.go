@@ -1,6 +1,10 @@
 package main
 
 import (
+	"context"
+	"errors"
+	"net/netip"
+	"slices"
 	"strconv"
 	"strings"
 	"time"
@@ -13,7 +17,13 @@ import (
 )
 
 func serv(port string, state *types.State) {
-	app := fiber.New()
+	app := fiber.New(fiber.Config{
+		// bodies past the default limit are left unread, so an uploaded shodan export is
+		// streamed to a temporary file instead of held in memory
+		StreamRequestBody:            true,
+		DisablePreParseMultipartForm: true,
+	})
+	app.Use(limitBody)
 
 	app.Get("/", func(c *fiber.Ctx) error {
 		c.Set("Content-Type", "text/html")
@@ -29,6 +39,9 @@ func serv(port string, state *types.State) {
 	servCsv(app, state)
 	servPortViewer(app, state)
 	servOsint(app, state)
+	servOrgs(app, state)
+	servHostCache(app, state)
+	servScope(app, state)
 
 	app.Static("/style.css", "./resources/style.css")
 
@@ -72,7 +85,7 @@ func servOpenPort(app *fiber.App, state *types.State) {
 			return c.SendString(t.BuildPage(t.OpenPortForm(types.Open, state.Name, state.Events), state))
 		}
 
-		return c.SendString(t.BuildPage(t.OpenPortDownload(), state))
+		return c.SendString(t.BuildPage(t.OpenPortDownload(state.Orgs.List()), state))
 	})
 
 	//makes a new file
@@ -92,6 +105,7 @@ func servOpenPort(app *fiber.App, state *types.State) {
 		state.Title = "Threat Intel Summary"
 		state.Tlp = form.Tlp
 		state.Report = types.Header
+		state.Warning = alerts.IncompleteWarning(form.Events)
 
 		return c.Redirect("/preview")
 	})
@@ -100,21 +114,45 @@ func servOpenPort(app *fiber.App, state *types.State) {
 	app.Put("/openport", func(c *fiber.Ctx) error {
 		state.Events = []*alerts.Event{}
 		state.Name = ""
-		return c.SendString(t.BuildPage(t.OpenPortDownload(), state))
+		return c.SendString(t.BuildPage(t.OpenPortDownload(state.Orgs.List()), state))
 	})
 
 	app.Post("/openport/form", func(c *fiber.Ctx) error {
 		name := c.FormValue("orgName")
-		ips := c.FormValue("ipAddress")
+		scope := alerts.ParseScope(c.FormValue("ipAddress"))
+		client, export, err := formClient(c, state)
+		if err == nil {
+			err = scope.Err()
+		}
+		if err != nil {
+			state.Warning = err.Error()
+			return c.SendString(t.BuildPage(t.OpenPortDownload(state.Orgs.List()), state))
+		}
+		if export != nil && len(scope.Ranges) == 0 {
+			scope = export.Scope()
+		}
 
-		events := alerts.DownloadIpList(name, ips)
+		events, warning := alerts.DownloadIpList(client, name, scope.Query())
+		scope.LabelHosts(events)
 
 		state.Events = events
+		state.Warning = warning
 		state.Name = strings.Clone(name)
 
 		return c.SendString(t.BuildPage(t.OpenPortForm(types.Open, state.Name, state.Events), state))
 	})
 
+	// loads the hosts that failed again
+	app.Put("/openport/retry", func(c *fiber.Ctx) error {
+		alerts.SharedLimiter().ForEach(len(state.Events), func(i int) {
+			if state.Events[i].Incomplete() {
+				state.Events[i].Retry(alerts.ForceRefresh(state.Client))
+			}
+		})
+
+		return c.SendString(t.BuildPage(t.OpenPortForm(types.Open, state.Name, state.Events), state))
+	})
+
 	app.Get("/openport/port", func(c *fiber.Ctx) error {
 		return c.SendString(t.BuildPage(t.OpenPortForm(types.Open, state.Name, state.Events), state))
 	})
@@ -165,6 +203,11 @@ func servActor(app *fiber.App, state *types.State) {
 }
 
 func servEvents(app *fiber.App, state *types.State) {
+	app.Get("/event/quarantine", func(c *fiber.Ctx) error {
+		c.Set("Content-Type", "text/html")
+		return c.SendString(t.BuildPage(t.Quarantine(state.Quarantined(), state.EventIndex), state))
+	})
+
 	app.Get("/event/page/:index", func(c *fiber.Ctx) error {
 		c.Set("Content-Type", "text/html")
 
@@ -177,18 +220,19 @@ func servEvents(app *fiber.App, state *types.State) {
 
 		state.EventIndex = index
 
-		return c.SendString(t.BuildPage(t.EventList(state.FeedEvents, index), state))
+		return c.SendString(t.BuildPage(t.EventList(state.FeedEvents(), len(state.Quarantined()), index), state))
 	})
 
 	app.Get("/event/open/:index", func(c *fiber.Ctx) error {
 		indexParam := c.Params("index")
 
 		index, err := strconv.Atoi(indexParam)
-		if err != nil || index < 0 || index >= len(state.FeedEvents) {
+		if err != nil || index < 0 || index >= len(state.FeedEvents()) {
 			return c.SendStatus(fiber.StatusBadRequest)
 		}
 
 		event := state.GetFeedEvent(index)
+		waitForEvent(event)
 		return c.SendString(t.BuildPage(t.EventView(event, index, types.Open, state.EventIndex), state))
 	})
 
@@ -196,11 +240,12 @@ func servEvents(app *fiber.App, state *types.State) {
 		indexParam := c.Params("index")
 
 		index, err := strconv.Atoi(indexParam)
-		if err != nil || index < 0 || index >= len(state.FeedEvents) {
+		if err != nil || index < 0 || index >= len(state.FeedEvents()) {
 			return c.SendStatus(fiber.StatusBadRequest)
 		}
 
 		event := state.GetFeedEvent(index)
+		waitForEvent(event)
 		return c.SendString(t.BuildPage(t.EventView(event, index, types.EOL, state.EventIndex), state))
 	})
 
@@ -208,11 +253,12 @@ func servEvents(app *fiber.App, state *types.State) {
 		indexParam := c.Params("index")
 
 		index, err := strconv.Atoi(indexParam)
-		if err != nil || index < 0 || index >= len(state.FeedEvents) {
+		if err != nil || index < 0 || index >= len(state.FeedEvents()) {
 			return c.SendStatus(fiber.StatusBadRequest)
 		}
 
 		event := state.GetFeedEvent(index)
+		waitForEvent(event)
 		return c.SendString(t.BuildPage(t.EventView(event, index, types.Login, state.EventIndex), state))
 	})
 
@@ -222,12 +268,15 @@ func servEvents(app *fiber.App, state *types.State) {
 		indexParam := c.Params("index")
 
 		index, err := strconv.Atoi(indexParam)
-		if err != nil || index < 0 || index >= len(state.FeedEvents) {
+		if err != nil || index < 0 || index >= len(state.FeedEvents()) {
 			return c.SendStatus(fiber.StatusBadRequest)
 		}
 
 		event := state.GetFeedEvent(index)
-		return c.SendString(t.BuildPage(t.EventView(event, index, types.Open, state.EventIndex), state))
+		waitForEvent(event)
+		// the trigger that fired decides which form opens
+		form := types.FormForTrigger(event.Trigger).Form
+		return c.SendString(t.BuildPage(t.EventView(event, index, form, state.EventIndex), state))
 	})
 
 	app.Post("/event/:index", func(c *fiber.Ctx) error {
@@ -236,42 +285,72 @@ func servEvents(app *fiber.App, state *types.State) {
 		indexParam := c.Params("index")
 
 		index, err := strconv.Atoi(indexParam)
-		if err != nil || index < 0 || index >= len(state.FeedEvents) {
+		if err != nil || index < 0 || index >= len(state.FeedEvents()) {
 			return c.SendStatus(fiber.StatusBadRequest)
 		}
+		event := state.GetFeedEvent(index)
+		event.Wait(c.Context())
 		form := createform.OpenPort{
-			OrgName:    state.FeedEvents[index].Name,
+			OrgName:    event.Name(),
 			FormNumber: c.FormValue("formNumber"),
 			Threat:     c.FormValue("threat"),
 			Summary:    c.FormValue("summary"),
 			Body:       c.FormValue("body"),
 			Reference:  c.FormValue("reference"),
 			Tlp:        c.FormValue("tlp") == "amber",
-			Events:     []*alerts.Event{state.FeedEvents[index]},
+			Events:     []*alerts.Event{event},
 		}
 		state.Markdown = form.CreateMarkdown(state)
 		state.Name = strings.Clone(form.OrgName)
 		state.Title = "Threat Intel Summary"
 		state.Tlp = form.Tlp
 		state.Report = types.Header
+		state.Warning = alerts.IncompleteWarning(form.Events)
 
 		return c.Redirect("/preview")
 	})
 
+	app.Put("/event/retry/:index", func(c *fiber.Ctx) error {
+		indexParam := c.Params("index")
+
+		index, err := strconv.Atoi(indexParam)
+		if err != nil || index < 0 || index >= len(state.FeedEvents()) {
+			return c.SendStatus(fiber.StatusBadRequest)
+		}
+
+		event := state.GetFeedEvent(index)
+		event.Retry(alerts.ForceRefresh(state.Client))
+		// reopens the form the trigger picks, like /event/:index does
+		form := types.FormForTrigger(event.Trigger).Form
+		return c.SendString(t.BuildPage(t.EventView(event, index, form, state.EventIndex), state))
+	})
+
+	// downloads the feed again without forgetting which events have been seen
+	app.Put("/event/refresh", func(c *fiber.Ctx) error {
+		state.SetFeedEvents(alerts.DownloadRss(state.Client))
+		state.EventIndex = 0
+
+		return c.SendString(t.BuildPage(t.EventList(state.FeedEvents(), len(state.Quarantined()), state.EventIndex), state))
+	})
+
 	app.Put("/event/reset", func(c *fiber.Ctx) error {
 		cache := alerts.NewEventCache()
 		cache.ClearTable()
 
-		events := alerts.DownloadRss()
-		state.FeedEvents = events
-		state.LoadEvents()
+		state.SetFeedEvents(alerts.DownloadRss(state.Client))
 		state.EventIndex = 0
-		time.Sleep(time.Duration(2 * time.Second))
 
-		return c.SendString(t.BuildPage(t.EventList(state.FeedEvents, state.EventIndex), state))
+		return c.SendString(t.BuildPage(t.EventList(state.FeedEvents(), len(state.Quarantined()), state.EventIndex), state))
 	})
 }
 
+// gives an event that is still loading a moment to finish before its page is rendered
+func waitForEvent(event *alerts.Event) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	event.Wait(ctx)
+}
+
 func servMarkdown(app *fiber.App, state *types.State) {
 	app.Get("/preview", func(c *fiber.Ctx) error {
 		c.Set("Content-Type", "text/html")
@@ -312,11 +391,37 @@ func servCsv(app *fiber.App, state *types.State) {
 
 	app.Post("/csv", func(c *fiber.Ctx) error {
 		name := c.FormValue("orgName")
-		query := c.FormValue("ipAddress")
+		scope := alerts.ParseScope(c.FormValue("ipAddress"))
+		c.Set("Content-Type", "text/html")
+
+		// the search filters narrow the addresses down, or search on their own without any
+		query := scope.Search()
+		client, export, err := formClient(c, state)
+		if err == nil {
+			err = scope.Err()
+		}
+		if err == nil {
+			err = query.AddFields(searchFields(c, "search"))
+		}
+		if err == nil && query.Empty() && export != nil {
+			query = export.Scope().Search()
+		}
+		if err == nil && query.Empty() {
+			err = errors.New("no ip addresses or search filters were given")
+		}
+		if err != nil {
+			// tells the page not to download the csv
+			c.Set("X-Scope-Error", "true")
+			state.Warning = err.Error()
+			return c.SendString(t.Warning(state))
+		}
 
 		state.Name = strings.Clone(name)
-		state.Markdown = createform.CreateCsv(query)
-		return c.SendStatus(fiber.StatusOK)
+		csv, warning := createform.CreateCsv(client, query.String())
+		state.Markdown = csv
+		state.Warning = warning
+
+		return c.SendString(t.Warning(state))
 	})
 
 	app.Get("/csv/create", func(c *fiber.Ctx) error {
@@ -332,10 +437,26 @@ func servPortViewer(app *fiber.App, state *types.State) {
 	})
 
 	app.Post("/portview", func(c *fiber.Ctx) error {
-		ips := c.FormValue("ipAddress")
+		scope := alerts.ParseScope(c.FormValue("ipAddress"))
+		client, export, err := formClient(c, state)
+		if err == nil {
+			err = scope.Err()
+		}
+		if err != nil {
+			state.Warning = err.Error()
+			c.Set("Content-Type", "text/html")
+			return c.SendString(t.BuildPage(t.PortViewer(), state))
+		}
+		if export != nil && len(scope.Ranges) == 0 {
+			scope = export.Scope()
+		}
+
+		events, warning := alerts.DownloadIpList(client, "", scope.Query())
+		scope.LabelHosts(events)
 		form := createform.PortViewer{
-			Events: alerts.DownloadIpList("", ips),
+			Events: events,
 		}
+		state.Warning = strings.TrimSpace(warning + " " + alerts.IncompleteWarning(events))
 
 		state.Markdown = form.CreateMarkdown()
 		state.Name = ""
@@ -348,44 +469,77 @@ func servPortViewer(app *fiber.App, state *types.State) {
 func servOsint(app *fiber.App, state *types.State) {
 	app.Get("/osint", func(c *fiber.Ctx) error {
 		c.Set("Content-Type", "text/html")
-		return c.SendString(t.BuildPage(t.Osint(), state))
+		return c.SendString(t.BuildPage(t.Osint(state.Orgs.List()), state))
 	})
 
 	app.Post("/osint", func(c *fiber.Ctx) error {
 		name := strings.Clone(c.FormValue("orgName"))
-		inScope := c.FormValue("inScope")
-		inScopeList := strings.FieldsFunc(inScope, func(r rune) bool {
-			return r == ',' || r == ' '
-		})
-
-		outScope := c.FormValue("outScope")
-		outScopeList := strings.FieldsFunc(outScope, func(r rune) bool {
-			return r == ',' || r == ' '
-		})
-
-		inScopEvents := []*alerts.Event{}
-		if inScope != "" {
-			inScopEvents = alerts.DownloadIpList(name, inScope)
+		inScope := alerts.ParseScope(c.FormValue("inScope"))
+		outScope := alerts.ParseScope(c.FormValue("outScope"))
+		urlScope := alerts.ParseScope(c.FormValue("urlIps"))
+		outSearch := alerts.Query{}
+		client, export, exportErr := formClient(c, state)
+		errs := []error{exportErr, urlScope.Err(), inScope.Err(), outScope.Err(), outSearch.AddFields(searchFields(c, "outScopeSearch"))}
+		for _, err := range errs {
+			if err != nil {
+				state.Warning = err.Error()
+				c.Set("Content-Type", "text/html")
+				return c.SendString(t.BuildPage(t.Osint(state.Orgs.List()), state))
+			}
+		}
+		// with no addresses given every host in the export is taken as in scope
+		source := "Shodan"
+		if export != nil {
+			source = export.Name
+			if len(inScope.Ranges) == 0 && len(outScope.Ranges) == 0 {
+				inScope = export.Scope()
+			}
 		}
 
-		outScopeEvents := []*alerts.Event{}
-		if outScope != "" {
-			outScopeEvents = alerts.DownloadIpList(name, outScope)
+		inScopEvents, inScopeWarning := alerts.DownloadIpList(client, name, inScope.Query())
+		outScopeEvents, outScopeWarning := alerts.DownloadIpList(client, name, outScope.Query())
+		searchEvents, searchWarning := alerts.DownloadIpList(client, name, outSearch.String())
+		inScope.LabelHosts(inScopEvents)
+		outScope.LabelHosts(outScopeEvents)
+
+		// hosts the search finds that were already given are left to their own list
+		searchIps := []string{}
+		for _, e := range searchEvents {
+			if addr, err := netip.ParseAddr(e.Ip); err == nil && (inScope.Contains(addr) || outScope.Contains(addr)) {
+				continue
+			}
+			searchIps = append(searchIps, e.Ip)
+			outScopeEvents = append(outScopeEvents, e)
 		}
 
 		events := append(outScopeEvents, inScopEvents...)
+		incompleteWarning := alerts.IncompleteWarning(events)
 		events = alerts.FilterCveEvents(events)
 
 		recordedFutureCreds := alerts.ParseCredentialDump(c.FormValue("recordedFutureCreds"))
 		otherCreds := alerts.ParseOtherCreds(c.FormValue("otherCreds"))
 
-		urlIps := c.FormValue("urlIps")
-		urlCount := strings.FieldsFunc(urlIps, func(r rune) bool {
-			return r == ',' || r == ' '
-		})
-
-		vulnerableUrls := len(urlCount)
-		urlEvents := alerts.DownloadIpList("", urlIps)
+		urlEvents, urlWarning := alerts.DownloadIpList(client, "", urlScope.Query())
+
+		// records the searches the results came from
+		queries := []createform.OsintQuery{}
+		searches := []struct {
+			purpose string
+			query   string
+			hosts   int
+		}{
+			{"In scope", inScope.Query(), len(inScopEvents)},
+			{"Out of scope", outScope.Query(), len(outScopeEvents) - len(searchIps)},
+			{"Out of scope search", outSearch.String(), len(searchEvents)},
+			{"Website", urlScope.Query(), len(urlEvents)},
+		}
+		for _, search := range searches {
+			if search.query != "" {
+				queries = append(queries, createform.OsintQuery{Purpose: search.purpose, Query: search.query, Source: source, Hosts: search.hosts})
+			}
+		}
+		// the website hosts shodan has vulnerabilities for
+		vulnerableUrls := len(alerts.FilterCveEvents(urlEvents))
 
 		creds := append(recordedFutureCreds, otherCreds...)
 		creds = alerts.SortCreds(creds)
@@ -393,8 +547,14 @@ func servOsint(app *fiber.App, state *types.State) {
 
 		form := createform.Osint{
 			Name: name, 
-			InScope: inScopeList,
-			OutScope: outScopeList,
+			InScope: inScope.Strings(),
+			OutScope: outScope.Strings(),
+			InScopeCount: inScope.Count().String(),
+			OutScopeCount: outScope.Count().String(),
+			InScopeHosts: inScope.Hosts,
+			OutScopeHosts: outScope.Hosts,
+			SearchIps: searchIps,
+			Queries: queries,
 			Events: events,
 			Creds: creds,
 			Url: c.FormValue("url"),
@@ -408,8 +568,179 @@ func servOsint(app *fiber.App, state *types.State) {
 		state.Title = form.Name
 		state.Report = types.Cover
 		state.Markdown = form.CreateMarkdown()
+		incompleteWarning += " " + alerts.IncompleteWarning(urlEvents)
+		state.Warning = strings.TrimSpace(strings.Join([]string{inScopeWarning, outScopeWarning, searchWarning, urlWarning, incompleteWarning}, " "))
 
 
 		return c.Redirect("/preview")
 	})
 }
+
+// forms with the force refresh box checked skip the host cache
+func requestClient(c *fiber.Ctx, state *types.State) alerts.ShodanClient {
+	if c.FormValue("refresh") == "on" {
+		return alerts.ForceRefresh(state.Client)
+	}
+	return state.Client
+}
+
+// the largest shodan export that can be uploaded
+const maxUploadSize = 512 << 20
+
+// the forms that take a shodan export
+var uploadPaths = []string{"/openport/form", "/csv", "/portview", "/osint"}
+
+// only the forms taking an export accept bodies past the default limit, every other
+// route would read a large body into memory whole
+func limitBody(c *fiber.Ctx) error {
+	limit := fiber.DefaultBodyLimit
+	if c.Method() == fiber.MethodPost && slices.Contains(uploadPaths, c.Path()) {
+		limit = maxUploadSize
+	}
+
+	length := c.Request().Header.ContentLength()
+	if length == -1 {
+		// a chunked body gives no length to check up front
+		return fiber.ErrLengthRequired
+	}
+	if length > limit {
+		return fiber.ErrRequestEntityTooLarge
+	}
+	return c.Next()
+}
+
+// the client a form's searches go to, an uploaded shodan export is searched in place of
+// shodan. The export is nil when none was uploaded
+func formClient(c *fiber.Ctx, state *types.State) (alerts.ShodanClient, *alerts.ExportClient, error) {
+	header, err := c.FormFile("export")
+	if err != nil || header.Size == 0 {
+		return requestClient(c, state), nil, nil
+	}
+
+	file, err := header.Open()
+	if err != nil {
+		return nil, nil, err
+	}
+	defer file.Close()
+
+	export, err := alerts.ReadExport(header.Filename, file)
+	if err != nil {
+		return nil, nil, err
+	}
+	return export, export, nil
+}
+
+func servOrgs(app *fiber.App, state *types.State) {
+	// lists the alerts from shodan, keeping the saved orgs in step with them
+	orgsPage := func() string {
+		index, err := alerts.DownloadAlerts(state.Client)
+		if err != nil {
+			state.Warning = "Could not list the Shodan network alerts: " + err.Error()
+			return t.BuildPage(t.Orgs(nil, state.Orgs.List(), nil), state)
+		}
+		state.Orgs.Sync(index)
+
+		triggers, err := alerts.DownloadTriggers(state.Client)
+		if err != nil {
+			state.Warning = "Could not list the Shodan alert triggers: " + err.Error()
+		}
+
+		return t.BuildPage(t.Orgs(index, state.Orgs.List(), triggers), state)
+	}
+
+	orgsResult := func(c *fiber.Ctx, err error) error {
+		if err != nil {
+			state.Warning = err.Error()
+		}
+		c.Set("Content-Type", "text/html")
+		return c.SendString(orgsPage())
+	}
+
+	app.Get("/orgs", func(c *fiber.Ctx) error {
+		return orgsResult(c, nil)
+	})
+
+	app.Post("/orgs", func(c *fiber.Ctx) error {
+		triggers := []string{}
+		for _, trigger := range c.Request().PostArgs().PeekMulti("triggers") {
+			triggers = append(triggers, string(trigger))
+		}
+
+		scope := alerts.ParseScope(c.FormValue("ranges"))
+		if err := scope.Err(); err != nil {
+			return orgsResult(c, err)
+		}
+
+		_, err := alerts.CreateAlert(state.Client, c.FormValue("orgName"), scope.Cidrs(), triggers)
+		return orgsResult(c, err)
+	})
+
+	app.Post("/orgs/:id", func(c *fiber.Ctx) error {
+		scope := alerts.ParseScope(c.FormValue("ranges"))
+		if err := scope.Err(); err != nil {
+			return orgsResult(c, err)
+		}
+
+		err := alerts.UpdateAlert(state.Client, c.Params("id"), scope.Cidrs())
+		return orgsResult(c, err)
+	})
+
+	app.Delete("/orgs/:id", func(c *fiber.Ctx) error {
+		err := state.Client.DeleteAlert(c.Params("id"))
+		if err == nil {
+			state.Orgs.Delete(c.Params("id"))
+		}
+		return orgsResult(c, err)
+	})
+
+	app.Put("/orgs/:id/trigger", func(c *fiber.Ctx) error {
+		err := state.Client.EnableTrigger(c.Params("id"), c.FormValue("trigger"))
+		return orgsResult(c, err)
+	})
+
+	app.Delete("/orgs/:id/trigger/:trigger", func(c *fiber.Ctx) error {
+		err := state.Client.DisableTrigger(c.Params("id"), c.Params("trigger"))
+		return orgsResult(c, err)
+	})
+}
+
+
+func servHostCache(app *fiber.App, state *types.State) {
+	app.Get("/cache", func(c *fiber.Ctx) error {
+		c.Set("Content-Type", "text/html")
+		return c.SendString(t.BuildPage(t.HostCache(state.HostCache.Stats()), state))
+	})
+
+	app.Delete("/cache", func(c *fiber.Ctx) error {
+		state.HostCache.ClearTable()
+
+		c.Set("Content-Type", "text/html")
+		return c.SendString(t.BuildPage(t.HostCache(state.HostCache.Stats()), state))
+	})
+}
+
+func servScope(app *fiber.App, state *types.State) {
+	// checks an address field as it is typed
+	app.Post("/scope/:field", func(c *fiber.Ctx) error {
+		c.Set("Content-Type", "text/html")
+		return c.SendString(t.ScopeSummary(alerts.ParseScope(c.FormValue(c.Params("field")))))
+	})
+
+	// shows the query the search fields build as they are typed
+	app.Post("/search/:field", func(c *fiber.Ctx) error {
+		query := alerts.Query{}
+		err := query.AddFields(searchFields(c, c.Params("field")))
+
+		c.Set("Content-Type", "text/html")
+		return c.SendString(t.SearchSummary(query, err))
+	})
+}
+
+// the text of each filter field of a search input, keyed by filter name
+func searchFields(c *fiber.Ctx, field string) map[string]string {
+	fields := make(map[string]string)
+	for _, filter := range alerts.SearchFilters {
+		fields[filter.Name] = c.FormValue(field + "." + filter.Name)
+	}
+	return fields
+}
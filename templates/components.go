@@ -81,6 +81,8 @@ func Banner(state *types.State) string {
 							</details>
 						</li>
                         <li><a  role="button" class="contrast" href="/preview">Markdown Preview</a></li>
+                        <li><a  role="button" class="contrast" href="/orgs">Monitored Orgs</a></li>
+                        <li><a  role="button" class="contrast" href="/cache">Host Cache</a></li>
                     </ul>
             </nav>
         </div>
@@ -100,15 +102,27 @@ func header() string {
         `
 }
 
+// Shows the warning left by the last request, it is only shown once
+func Warning(state *types.State) string {
+	warning := state.Warning
+	state.Warning = ""
+
+	const page = `{{if .}}<article class="pico-background-amber-200" id="warning">{{.}}</article>{{end}}`
+
+	return Execute("warning", page, warning)
+}
+
 func BuildPage(body string, state *types.State) string {
 	data := struct {
-		Header string
-		Body   string
-		Banner string
+		Header  string
+		Body    string
+		Banner  string
+		Warning string
 	}{
-		Header: header(),
-		Body:   body,
-		Banner: Banner(state),
+		Header:  header(),
+		Body:    body,
+		Banner:  Banner(state),
+		Warning: Warning(state),
 	}
 
 	const page = `
@@ -118,6 +132,7 @@ func BuildPage(body string, state *types.State) string {
         <body hx-boost="true">
 	    {{.Banner}}
             <div class="center">
+                {{.Warning}}
                 {{.Body}}
             </div>
         </body>
@@ -1,12 +1,21 @@
 package templates
 
 func Csv() string {
+	data := struct {
+		IpInput     string
+		SearchInput string
+		ExportInput string
+	}{
+		IpInput:     ScopeInput("ipAddress", "IP Addresses or Hostnames"),
+		SearchInput: SearchInput("search", "Search Filters"),
+		ExportInput: ExportInput(),
+	}
 
+	// the file name comes from the Content-Disposition of /csv/create
 	const page = `
 	<script>
 		function download() {
 		  var link = document.createElement('a');
-		  link.download = "{{.Name}}";
 		  link.href = '/csv/create';
 		  link.target= '_blank';
 		  link.click();
@@ -15,16 +24,16 @@ func Csv() string {
 	</script>
 	<h1>CSV</h1>
 	<article>
-	    <form hx-post="/csv" hx-swap="none" hx-indicator="#load" hx-on::after-request="download()">
+	<div id="csvWarning"></div>
+	    <form hx-post="/csv" hx-target="#csvWarning" hx-indicator="#load" hx-encoding="multipart/form-data" hx-on::after-request="if (event.detail.elt === this && !event.detail.xhr.getResponseHeader('X-Scope-Error')) download()">
 		<fieldset>
 		    <label>
 			    Organization Name
 			    <input name="orgName"/>
 		    </label>
-		    <label>
-			    IP Addresses
-			    <input name="ipAddress" />
-		    </label>
+		    {{.IpInput}}
+		    {{.SearchInput}}
+		    {{.ExportInput}}
 
 		    <div id="load" class="htmx-indicator center" aria-busy="true">Loading...</div>
 		    <div class="grid">
@@ -36,5 +45,5 @@ func Csv() string {
 	</article>
     `
 
-	return ExecuteText("csv", page, nil)
+	return ExecuteText("csv", page, data)
 }
@@ -7,30 +7,42 @@ import (
 	"github.com/eagledb14/form-scanner/types"
 )
 
-func EventList(events []*alerts.Event, index int) string {
+func EventList(events []*alerts.Event, quarantined int, index int) string {
 
 	data := struct {
-		Events     []*alerts.Event
-		EventIndex int
-		NextIndex  int
-		PrevIndex  int
+		Events      []*alerts.Event
+		Quarantined int
+		EventIndex  int
+		NextIndex   int
+		PrevIndex   int
 	}{
-		Events:     paginate(events, index),
-		EventIndex: index,
-		NextIndex:  index + 1,
-		PrevIndex:  index - 1,
+		Events:      paginate(events, index),
+		Quarantined: quarantined,
+		EventIndex:  index,
+		NextIndex:   index + 1,
+		PrevIndex:   index - 1,
 	}
 
 	const page = `
 	<h1>Event: {{.EventIndex}} </h1>
-	<button class="outline secondary" id="reset" hx-put="/event/reset" hx-target="body" hx-indicator="#load">Clear Event Cache</button>
+	<div class="grid">
+		<button class="outline" id="refresh" hx-put="/event/refresh" hx-target="body" hx-indicator="#load">Refresh Feed</button>
+		<button class="outline secondary" id="reset" hx-put="/event/reset" hx-target="body" hx-indicator="#load">Clear Event Cache</button>
+	</div>
 	<div id="load" class="htmx-indicator center" aria-busy="true">Loading...</div>
+	{{if gt .Quarantined 0}}
+	<p><a href="/event/quarantine"><mark>{{.Quarantined}} Quarantined Items</mark></a></p>
+	{{end}}
 	{{if eq (len .Events) 0}}
 	<h2>No New Events</h2>
 	{{end}}
 	{{range $index, $event := .Events}}
 		<article>
-			<header>{{$event.Name}}</header>
+			{{if $event.Loaded}}
+			<header>{{$event.Name}}{{if $event.Incomplete}} <mark>Incomplete Data</mark>{{end}}</header>
+			{{else}}
+			<header aria-busy="true">Loading</header>
+			{{end}}
 			{{$event.Ip}}
 			<br>
 			{{$event.Desc}}
@@ -50,7 +62,46 @@ func EventList(events []*alerts.Event, index int) string {
 	return ExecuteText("event", page, data)
 }
 
+// feed items that could not be parsed, shown so they can be looked at by hand
+func Quarantine(items []alerts.QuarantinedItem, eventPage int) string {
+	data := struct {
+		Items     []alerts.QuarantinedItem
+		EventPage int
+	}{
+		Items:     items,
+		EventPage: eventPage,
+	}
+
+	const page = `
+	<h1>Quarantined Items</h1>
+	<a href="/event/page/{{.EventPage}}" class="unset"><button><</button></a>
+	{{if eq (len .Items) 0}}
+	<h2>No Quarantined Items</h2>
+	{{end}}
+	{{range .Items}}
+		<article>
+			<header>{{.Item.Title}}</header>
+			<small>{{.Reason}}</small>
+			<br>
+			{{.Item.Description}}
+			<br>
+			<small>{{.Item.PubDate}}</small>
+			{{if .Item.Link}}
+			<br>
+			<small><a href="{{.Item.Link}}" target=_blank>Alert Link</a></small>
+			{{end}}
+		</article>
+	{{end}}
+        `
+
+	return Execute("quarantine", page, data)
+}
+
 func EventView(event *alerts.Event, index int, form types.Form, eventPage int) string {
+	if !event.Loaded() {
+		return eventLoading(event, index, form, eventPage)
+	}
+
 	data := struct {
 		Name       string
 		Event      *alerts.Event
@@ -59,11 +110,11 @@ func EventView(event *alerts.Event, index int, form types.Form, eventPage int) s
 		Form       string
 		FormName   string
 	}{
-		Name:       event.Name,
+		Name:       event.Name(),
 		Event:      event,
 		EventPage:  eventPage,
 		EventIndex: index,
-		Form:       getForm(form, event.Name, []*alerts.Event{event}, "/event/"+strconv.Itoa(index)),
+		Form:       getForm(types.FormForEvent(event.Trigger, form), event.Name(), []*alerts.Event{event}, "/event/"+strconv.Itoa(index)),
 		FormName:   types.FormName[form],
 	}
 
@@ -78,13 +129,27 @@ func EventView(event *alerts.Event, index int, form types.Form, eventPage int) s
 			<br>
 			<small><a href="{{.Event.HostLink}}" target=_blank>Host Link</a></small>
 		</header>
+		{{if .Event.Incomplete}}
+			<mark>Incomplete Data</mark>
+			{{range .Event.Errors}}
+				<br>
+				<small>{{.Error}}</small>
+			{{end}}
+			<br>
+			<button class="outline" hx-put="/event/retry/{{.EventIndex}}" hx-target="body" hx-indicator="#load">Retry</button>
+			<div id="load" class="htmx-indicator center" aria-busy="true">Loading...</div>
+			<hr>
+		{{end}}
 		{{if eq (len .Event.Ports) 0}}
 			<h4> No Available Information</h4>
 		{{end}}
 		{{range $key, $value := $.Event.Ports}}
 			<h4>{{$key}}</h4>
+			{{with $.Event.ServiceLabel $key}}<small><b>{{html .}}</b></small><br>{{end}}
+			{{with $.Event.Eol $key}}<small><mark>End of life: {{html .Product}}{{with .Version}} {{html .}}{{end}} since {{.DateString}}</mark></small><br>{{end}}
+			{{with $.Event.Login $key}}<small><mark>Login page: {{html .Product}}</mark></small><br>{{end}}
 			{{range $value}}
-				<small>{{.Name}}: Priority {{.Rank}}</small>
+				<small>{{.Name}}: {{.Priority}}</small>
 				<br>
 			{{end}}
 			<hr>
@@ -103,6 +168,39 @@ func EventView(event *alerts.Event, index int, form types.Form, eventPage int) s
 	return ExecuteText("eventPager", page, data)
 }
 
+// shown while the event is still downloading, it asks for the page again until it has loaded
+func eventLoading(event *alerts.Event, index int, form types.Form, eventPage int) string {
+	routes := map[types.Form]string{
+		types.Open:  "/event/",
+		types.EOL:   "/event/eol/",
+		types.Login: "/event/login/",
+	}
+
+	data := struct {
+		Event     *alerts.Event
+		Route     string
+		EventPage int
+	}{
+		Event:     event,
+		Route:     routes[form] + strconv.Itoa(index),
+		EventPage: eventPage,
+	}
+
+	const page = `
+    <h1>Event</h1>
+	<a href="/event/page/{{.EventPage}}" class="unset"><button><</button></a>
+	<h6>{{.Event.Desc}}</h6>
+	<article hx-get="{{.Route}}" hx-trigger="load delay:1s" hx-target="body">
+		<header>
+			<h3>{{.Event.Ip}}</h3>
+		</header>
+		<div class="center" aria-busy="true">Loading...</div>
+	</article>
+        `
+
+	return ExecuteText("eventLoading", page, data)
+}
+
 func paginate(events []*alerts.Event, index int) []*alerts.Event {
 	const pageSize int = 10
 	entryNum := pageSize * index
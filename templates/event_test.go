@@ -0,0 +1,61 @@
+package templates
+
+import (
+	"errors"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+
+	"github.com/eagledb14/form-scanner/alerts"
+	"github.com/eagledb14/form-scanner/types"
+)
+
+// fails every other host lookup, so retries keep clearing and adding load errors.
+// Every other call panics on the nil client
+type flakyClient struct {
+	alerts.ShodanClient
+	lookups atomic.Int32
+}
+
+func (f *flakyClient) Host(ip string) ([]byte, error) {
+	if f.lookups.Add(1)%2 == 0 {
+		return nil, errors.New("connection reset")
+	}
+	return []byte(`{"ports": [22, 443], "data": [
+		{"port": 22, "transport": "tcp", "product": "OpenSSH", "version": "7.4"},
+		{"port": 443, "transport": "tcp", "product": "nginx", "version": "1.26.0"}
+	]}`), nil
+}
+
+// the event page is rendered while the event is retried, run with -race to see the
+// loaded fields are never read while a retry writes them
+func TestEventViewDuringRetry(t *testing.T) {
+	client := &flakyClient{}
+	event := alerts.NewEventFromIp("192.0.2.1")
+	event.Load(client)
+
+	wg := sync.WaitGroup{}
+	for i := 0; i < 20; i++ {
+		wg.Add(3)
+		go func() {
+			defer wg.Done()
+			event.Retry(client)
+		}()
+		go func() {
+			defer wg.Done()
+			page := EventView(event, 0, types.Open, 0)
+			if !strings.Contains(page, "192.0.2.1") && !strings.Contains(page, "Loading") {
+				t.Errorf("the event page rendered without the event: %s", page)
+			}
+			EventList([]*alerts.Event{event}, 0, 0)
+		}()
+		go func() {
+			defer wg.Done()
+			alerts.IncompleteWarning([]*alerts.Event{event})
+			alerts.FilterCveEvents([]*alerts.Event{event})
+			event.FilterCves()
+		}()
+	}
+	wg.Wait()
+}
@@ -0,0 +1,14 @@
+package templates
+
+// ExportInput uploads a shodan download file, which is searched in place of shodan
+func ExportInput() string {
+	const page = `
+	<label>
+		Shodan Export
+		<input type="file" name="export" accept=".gz,.json"/>
+		<small>A <code>shodan download</code> .json.gz file, searched in place of Shodan so no api key or network is needed. Leave the addresses empty to use every host in the file</small>
+	</label>
+	`
+
+	return Execute("exportInput", page, nil)
+}
@@ -8,31 +8,16 @@ import (
 )
 
 
-func getForm(formType types.Form, name string, events []*alerts.Event, endpoint string) string {
-	summary := ""
-	body := ""
-
-	// make a match on which type is passed int
-	switch formType {
-	case types.Open:
-		summary = OpenPortSummary(name, events)
-		body = OpenPortBody(name, events)
-	case types.EOL:
-		summary = endOfLifeSummary(name, events)
-		body = OpenPortBody(name, events)
-	case types.Login:
-		summary = loginPageSummary(name)
-		body = loginPageBody(name, events)
-	default:
-		summary = types.FormName[formType]
-		body = types.FormName[formType]
-	}
+func getForm(form types.TriggerForm, name string, events []*alerts.Event, endpoint string) string {
+	summary, body := FormText(form, name, events)
 
 	data := struct {
+		Threat string
 		Summary string
 		Body string
 		Endpoint string
 	} {
+		Threat: form.Threat,
 		Summary: summary,
 		Body: body,
 		Endpoint: endpoint,
@@ -49,7 +34,7 @@ func getForm(formType types.Form, name string, events []*alerts.Event, endpoint
 
 					<label>
 						Threat Type
-						<input name="threat" value="T1133 External Remote Services"/>
+						<input name="threat" value="{{.Threat}}"/>
 					</label>
 
 					<label>
@@ -90,10 +75,58 @@ func getForm(formType types.Form, name string, events []*alerts.Event, endpoint
 	return Execute("form", page, data)
 }
 
+// FormText is the summary and body a form starts with, from the form's templates when it has them
+func FormText(form types.TriggerForm, name string, events []*alerts.Event) (string, string) {
+	summary := ""
+	body := ""
+
+	// make a match on which type is passed int
+	switch form.Form {
+	case types.Open:
+		summary = OpenPortSummary(name, events)
+		body = OpenPortBody(name, events)
+	case types.EOL:
+		summary = endOfLifeSummary(name, events)
+		body = endOfLifeBody(name, events)
+	case types.Login:
+		summary = loginPageSummary(name)
+		body = loginPageBody(name, events)
+	default:
+		summary = types.FormName[form.Form]
+		body = types.FormName[form.Form]
+	}
+
+	ips := []string{}
+	triggers := []string{}
+	for _, e := range events {
+		ips = append(ips, e.Ip)
+		triggers = append(triggers, e.Trigger)
+	}
+
+	data := struct {
+		Name    string
+		Ips     string
+		Trigger string
+	}{
+		Name:    name,
+		Ips:     strings.Join(ips, ", "),
+		Trigger: strings.Join(triggers, ", "),
+	}
+
+	if form.Summary != "" {
+		summary = ExecuteText("summary", form.Summary, data)
+	}
+	if form.Body != "" {
+		body = ExecuteText("body", form.Body, data)
+	}
+
+	return summary, body
+}
+
 func OpenPortSummary(name string, events []*alerts.Event) string {
 	cves := false
 	outer: for _, e := range events {
-		for _, cve := range e.Ports {
+		for _, cve := range e.Ports() {
 			if len(cve) > 0 {
 				cves = true
 				break outer
@@ -127,7 +160,7 @@ func OpenPortBody(name string, events []*alerts.Event) string {
 func endOfLifeSummary(name string, events []*alerts.Event) string {
 	cves := false
 	outer: for _, e := range events {
-		for _, cve := range e.Ports {
+		for _, cve := range e.Ports() {
 			if len(cve) > 0 {
 				cves = true
 				break outer
@@ -142,21 +175,58 @@ func endOfLifeSummary(name string, events []*alerts.Event) string {
     }
 }
 
+func endOfLifeBody(name string, events []*alerts.Event) string {
+	eol := alerts.EolServices(events)
+	if len(eol) == 0 {
+		return OpenPortBody(name, events)
+	}
+
+	data := struct {
+		Name string
+		Eol  []alerts.EolService
+	}{
+		Name: name,
+		Eol:  eol,
+	}
+
+	const page = `Software that has reached end of life no longer receives security updates, so any vulnerability found in it stays exploitable. We encourage {{.Name}} to upgrade or retire the following end of life software and evaluate the risk of leaving it in its current state:
+{{range .Eol}}
+- {{.Ip}}:{{.Port}} {{.Product}}{{with .Version}} {{.}}{{end}}, end of life {{.DateString}}{{end}}
+
+We also encourage {{.Name}} to search for indicators of unauthorized access because threat actors exploit unsupported software often for initial access.`
+
+	return ExecuteText("endOfLifeBody", page, data)
+}
+
 func loginPageSummary(name string) string {
     return "The North Carolina National Guard Cyber Security Response Force (NCNG CSRF) received an alert indicating the " + name + " domain is publicly exposing risky login pages to the internet."
 }
 
 func loginPageBody(name string, events []*alerts.Event) string {
-	ips := strings.Builder{}
-	for _, e := range events {
-		ips.Write([]byte(e.Ip + ", "))
+	logins := alerts.LoginEndpoints(events)
+	if len(logins) == 0 {
+		return noLoginPageBody(name)
 	}
-	ipString := ""
-
-	if len(ips.String()) > 0 {
 
-		ipString = ips.String()[:len(ips.String()) - 2]
+	data := struct {
+		Name   string
+		Logins []alerts.LoginEndpoint
+	}{
+		Name:   name,
+		Logins: logins,
 	}
 
-    return "A threat actor may have an easier pathway to conducting a cyber attack or cyber espionage against your organization based on your current configuration, through repeated login attempts against possible weak user login credentials. We encourage " + name + " to review the infrastructure at the following IP addresses: " + ipString + " and evaluate the risk of leaving them in their current state. We also encourage " + name + " to search for indicators of unauthorized access because threat actors exploit this configuration often for initial access."
+	const page = `A threat actor may have an easier pathway to conducting a cyber attack or cyber espionage against your organization based on your current configuration, through repeated login attempts against possible weak user login credentials. We encourage {{.Name}} to review the following login pages and evaluate the risk of leaving them in their current state:
+{{range .Logins}}
+- {{.Url}} ({{.Product}}){{end}}
+
+We also encourage {{.Name}} to search for indicators of unauthorized access because threat actors exploit this configuration often for initial access.`
+
+	return ExecuteText("loginPageBody", page, data)
 }
+
+// the body used when no login page was found in the banners, so nothing is listed by mistake
+func noLoginPageBody(name string) string {
+	return "No login pages were detected in the Shodan data for " + name + ". Add the login pages to review before sending this report."
+}
+
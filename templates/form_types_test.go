@@ -0,0 +1,39 @@
+package templates
+
+import (
+	"testing"
+
+	"github.com/eagledb14/form-scanner/alerts"
+	"github.com/eagledb14/form-scanner/types"
+)
+
+func TestFormText(t *testing.T) {
+	events := []*alerts.Event{alerts.NewEventFromIp("192.0.2.1"), alerts.NewEventFromIp("192.0.2.2")}
+	events[0].Trigger = "open_database"
+	events[1].Trigger = "industrial_control_system"
+
+	form := types.TriggerForm{
+		Form:    types.Open,
+		Summary: "{{.Name}} exposes {{.Trigger}}",
+		Body:    "{{.Name}} should review {{.Ips}}",
+	}
+	summary, body := FormText(form, "Acme", events)
+	if summary != "Acme exposes open_database, industrial_control_system" {
+		t.Errorf("got summary %q", summary)
+	}
+	if body != "Acme should review 192.0.2.1, 192.0.2.2" {
+		t.Errorf("got body %q", body)
+	}
+
+	// a form without templates starts with its own text
+	summary, body = FormText(types.FormDefaults(types.Open), "Acme", events)
+	if summary != OpenPortSummary("Acme", events) || body != OpenPortBody("Acme", events) {
+		t.Errorf("got summary %q and body %q, want the open port text", summary, body)
+	}
+
+	// and a form with only a summary keeps its own body
+	form.Body = ""
+	if _, body = FormText(form, "Acme", events); body != OpenPortBody("Acme", events) {
+		t.Errorf("got body %q, want the open port body", body)
+	}
+}
@@ -0,0 +1,37 @@
+package templates
+
+import (
+	"github.com/eagledb14/form-scanner/alerts"
+)
+
+func HostCache(stats alerts.HostCacheStats) string {
+	data := struct {
+		Stats  alerts.HostCacheStats
+		Kb     int64
+		Oldest string
+		Newest string
+	}{
+		Stats:  stats,
+		Kb:     stats.Bytes / 1024,
+		Oldest: stats.Oldest.Format("2006-01-02 15:04"),
+		Newest: stats.Newest.Format("2006-01-02 15:04"),
+	}
+
+	const page = `
+	<h1>Host Cache</h1>
+	<article>
+		<table>
+			<tr><th>Cached Hosts</th><td>{{.Stats.Hosts}}</td></tr>
+			<tr><th>Size</th><td>{{.Kb}} KB</td></tr>
+			<tr><th>Time To Live</th><td>{{.Stats.Ttl}}</td></tr>
+			{{if gt .Stats.Hosts 0}}
+			<tr><th>Oldest Entry</th><td>{{.Oldest}}</td></tr>
+			<tr><th>Newest Entry</th><td>{{.Newest}}</td></tr>
+			{{end}}
+		</table>
+		<button class="outline secondary" hx-delete="/cache" hx-target="body" hx-confirm="Clear every cached host?">Clear Host Cache</button>
+	</article>
+	`
+
+	return Execute("hostCache", page, data)
+}
@@ -5,22 +5,32 @@ import (
 	"github.com/eagledb14/form-scanner/types"
 )
 
-func OpenPortDownload() string {
+func OpenPortDownload(orgs []alerts.Org) string {
 	data := struct {
-	}{}
+		OrgSelect   string
+		IpInput     string
+		ExportInput string
+	}{
+		OrgSelect:   OrgSelect(orgs, "ipAddress"),
+		IpInput:     ScopeInput("ipAddress", "IP Addresses or Hostnames"),
+		ExportInput: ExportInput(),
+	}
 
 	const page = `
         <h1>Open Port</h1>
 		<article>
-			<form hx-post="/openport/form" hx-target="body" hx-indicator="#load">
+			<form hx-post="/openport/form" hx-target="body" hx-indicator="#load" hx-encoding="multipart/form-data">
 				<fieldset>
+						{{.OrgSelect}}
 						<label>
 							Organization Name
 							<input name="orgName"/>
 						</label>
+						{{.IpInput}}
+						{{.ExportInput}}
 						<label>
-							IP Addresses
-							<input name="ipAddress" />
+							<input type="checkbox" name="refresh"/>
+							Force Refresh
 						</label>
 
 						<div id="load" class="htmx-indicator center" aria-busy="true">Loading...</div>
@@ -33,7 +43,7 @@ func OpenPortDownload() string {
 		</article>
         `
 
-	return Execute("openport", page, data)
+	return ExecuteText("openport", page, data)
 }
 
 func OpenPortForm(form types.Form, name string, e []*alerts.Event) string {
@@ -42,30 +52,47 @@ func OpenPortForm(form types.Form, name string, e []*alerts.Event) string {
 		Events []*alerts.Event
 		Form string
 		FormName string
+		Incomplete bool
 	}{
 		Name: name,
 		Events: e,
-		Form: getForm(form, name, e, "/openport"),
+		Incomplete: alerts.IncompleteWarning(e) != "",
+		Form: getForm(types.FormDefaults(form), name, e, "/openport"),
 		FormName: types.FormName[form],
 	}
 
 	const page = `
 		<button hx-put="/openport" hx-target="body"><</button>
         <h1>{{.Name}}</h1>
-		{{range .Events}}
+		{{if .Incomplete}}
+			<button class="outline" hx-put="/openport/retry" hx-target="body" hx-indicator="#load">Retry Incomplete Hosts</button>
+			<div id="load" class="htmx-indicator center" aria-busy="true">Loading...</div>
+		{{end}}
+		{{range $event := .Events}}
 			<article>
 				<header>
 					<h3>{{.Ip}}</h3> 
 					<br>
 					<small><a href="{{.HostLink}}" target=_blank>Host Link</a></small>
 				</header>
+				{{if .Incomplete}}
+					<mark>Incomplete Data</mark>
+					{{range .Errors}}
+						<br>
+						<small>{{.Error}}</small>
+					{{end}}
+					<hr>
+				{{end}}
 				{{if eq (len .Ports) 0}}
 					<h4> No Available Information</h4>
 				{{end}}
 				{{range $key, $value := .Ports}}
 					<h4>{{$key}}</h4>
+					{{with $event.ServiceLabel $key}}<small><b>{{html .}}</b></small><br>{{end}}
+					{{with $event.Eol $key}}<small><mark>End of life: {{html .Product}}{{with .Version}} {{html .}}{{end}} since {{.DateString}}</mark></small><br>{{end}}
+					{{with $event.Login $key}}<small><mark>Login page: {{html .Product}}</mark></small><br>{{end}}
 					{{range $value}}
-						<small>{{.Name}}: Priority {{.Rank}}</small>
+						<small>{{.Name}}: {{.Priority}}</small>
 						<br>
 					{{end}}
 					<hr>
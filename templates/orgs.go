@@ -0,0 +1,129 @@
+package templates
+
+import (
+	"github.com/eagledb14/form-scanner/alerts"
+)
+
+// Orgs lists the network alerts on the account. When shodan can't be reached
+// index is nil and the saved orgs are shown without any way to change them
+func Orgs(index *alerts.AlertIndex, saved []alerts.Org, triggers []alerts.Trigger) string {
+	data := struct {
+		Index    *alerts.AlertIndex
+		Saved    []alerts.Org
+		Triggers []alerts.Trigger
+	}{
+		Index:    index,
+		Saved:    saved,
+		Triggers: triggers,
+	}
+
+	const page = `
+	<h1>Monitored Orgs</h1>
+	<div id="load" class="htmx-indicator center" aria-busy="true">Loading...</div>
+	{{if .Index}}
+	<article>
+		<header>New Org</header>
+		<form hx-post="/orgs" hx-target="body" hx-indicator="#load">
+			<fieldset>
+				<label>
+					Organization Name
+					<input name="orgName"/>
+				</label>
+				<label>
+					IP Ranges
+					<input name="ranges"/>
+				</label>
+				<details>
+					<summary>Triggers</summary>
+					{{range .Triggers}}
+					<label>
+						<input type="checkbox" name="triggers" value="{{.Name}}"/>
+						{{.Name}} <small>{{.Description}}</small>
+					</label>
+					{{end}}
+				</details>
+				<input type="submit" value="Create"/>
+			</fieldset>
+		</form>
+	</article>
+
+	{{range .Index.Alerts}}
+	<article>
+		<header>
+			<h3>{{.Name}}</h3>
+			<small>{{.Id}}</small>
+		</header>
+		<form hx-post="/orgs/{{.Id}}" hx-target="body" hx-indicator="#load">
+			<fieldset role="group">
+				<input name="ranges" value="{{range $i, $ip := .Filters.Ip}}{{if $i}}, {{end}}{{$ip}}{{end}}"/>
+				<input type="submit" value="Update"/>
+			</fieldset>
+		</form>
+		<label>Triggers</label>
+		{{$id := .Id}}
+		{{range .TriggerNames}}
+			<button class="outline secondary" hx-delete="/orgs/{{$id}}/trigger/{{.}}" hx-target="body" hx-indicator="#load">{{.}} ✕</button>
+		{{end}}
+		<form hx-put="/orgs/{{.Id}}/trigger" hx-target="body" hx-indicator="#load">
+			<fieldset role="group">
+				<select name="trigger">
+					{{range $.Triggers}}
+					<option value="{{.Name}}">{{.Name}}</option>
+					{{end}}
+				</select>
+				<input type="submit" value="Enable"/>
+			</fieldset>
+		</form>
+		<hr>
+		<button class="outline contrast" hx-delete="/orgs/{{.Id}}" hx-target="body" hx-indicator="#load" hx-confirm="Delete the {{.Name}} alert from Shodan?">Delete</button>
+	</article>
+	{{end}}
+	{{else}}
+	<h4>Shodan could not be reached, showing the saved orgs</h4>
+	{{range .Saved}}
+	<article>
+		<header>{{.Name}}</header>
+		{{.RangeString}}
+	</article>
+	{{end}}
+	{{end}}
+	`
+
+	return Execute("orgs", page, data)
+}
+
+// a dropdown of the saved orgs that fills in the org name and the ranges input
+func OrgSelect(orgs []alerts.Org, rangeInput string) string {
+	data := struct {
+		Orgs       []alerts.Org
+		RangeInput string
+	}{
+		Orgs:       orgs,
+		RangeInput: rangeInput,
+	}
+
+	const page = `
+	{{if gt (len .Orgs) 0}}
+	<script>
+		function fillOrg(select, rangeInput) {
+		  var option = select.options[select.selectedIndex];
+		  document.getElementsByName("orgName")[0].value = option.value;
+		  var input = document.getElementsByName(rangeInput)[0];
+		  input.value = option.dataset.ranges;
+		  input.dispatchEvent(new Event("input"));
+		}
+	</script>
+	<label>
+		Saved Org
+		<select data-range-input="{{.RangeInput}}" onchange="fillOrg(this, this.dataset.rangeInput)">
+			<option value="" data-ranges="" selected></option>
+			{{range .Orgs}}
+			<option value="{{.Name}}" data-ranges="{{.RangeString}}">{{.Name}}</option>
+			{{end}}
+		</select>
+	</label>
+	{{end}}
+	`
+
+	return Execute("orgSelect", page, data)
+}
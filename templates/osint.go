@@ -1,22 +1,33 @@
 package templates
 
 import (
-
+	"github.com/eagledb14/form-scanner/alerts"
 )
 
-func Osint() string {
+func Osint(orgs []alerts.Org) string {
     data := struct {
-
+	OrgSelect string
+	UrlIpsInput string
+	InScopeInput string
+	OutScopeInput string
+	OutScopeSearchInput string
+	ExportInput string
     } {
-
+	OrgSelect: OrgSelect(orgs, "inScope"),
+	UrlIpsInput: ScopeInput("urlIps", "Url IPs or Hostnames"),
+	InScopeInput: ScopeInput("inScope", "In Scope IP Addresses or Hostnames"),
+	OutScopeInput: ScopeInput("outScope", "Out of Scope IP Addresses or Hostnames"),
+	OutScopeSearchInput: SearchInput("outScopeSearch", "Out of Scope Search"),
+	ExportInput: ExportInput(),
     }
 
 
     const page = `
 <h1>Osint</h1>
 <article>
-    <form hx-post="/osint" hx-target="body" hx-push-url="preview" hx-indicator="#load">
+    <form hx-post="/osint" hx-target="body" hx-push-url="preview" hx-indicator="#load" hx-encoding="multipart/form-data">
 	<fieldset>
+	    {{.OrgSelect}}
 	    <label>
 		Organization Name
 		<input name="orgName"/>
@@ -26,20 +37,17 @@ func Osint() string {
 		<input name="url"/>
 	    </label>
 
-	    <label>
-		Url IPs
-		<input name="urlIps" />
-	    </label>
+	    {{.UrlIpsInput}}
 
 	    <hr>
 
+	    {{.InScopeInput}}
+	    {{.OutScopeInput}}
+	    {{.OutScopeSearchInput}}
+	    {{.ExportInput}}
 	    <label>
-		In Scope IP Addresses
-		<input name="inScope">
-	    </label>
-	    <label>
-		Out of Scope IP Addresses
-		<input name="outScope">
+		<input type="checkbox" name="refresh"/>
+		Force Refresh
 	    </label>
 
 	    <hr>
@@ -125,5 +133,5 @@ func Osint() string {
 </article>
 `
 
-    return Execute("osint", page, data)
+    return ExecuteText("osint", page, data)
 }
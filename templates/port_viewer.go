@@ -2,16 +2,23 @@ package templates
 
 func PortViewer() string {
 	data := struct {
-	}{}
+		IpInput     string
+		ExportInput string
+	}{
+		IpInput:     ScopeInput("ipAddress", "IP Addresses or Hostnames"),
+		ExportInput: ExportInput(),
+	}
 
 	const page = `
         <h1>Port Viewer</h1>
 		<article>
-			<form hx-post="/portview" hx-target="body" hx-indicator="#load">
+			<form hx-post="/portview" hx-target="body" hx-indicator="#load" hx-encoding="multipart/form-data">
 				<fieldset>
+						{{.IpInput}}
+						{{.ExportInput}}
 						<label>
-							IP Addresses
-							<input name="ipAddress" />
+							<input type="checkbox" name="refresh"/>
+							Force Refresh
 						</label>
 
 						<div id="load" class="htmx-indicator center" aria-busy="true">Loading...</div>
@@ -24,6 +31,6 @@ func PortViewer() string {
 		</article>
         `
 
-	return Execute("portViewer", page, data)
+	return ExecuteText("portViewer", page, data)
 }
 
@@ -0,0 +1,52 @@
+package templates
+
+import (
+	"github.com/eagledb14/form-scanner/alerts"
+)
+
+// SearchInput is a field for each shodan search filter, showing the query they build as they change.
+// The fields are named after the search and the filter, like "outScopeSearch.org", and only they
+// are sent when the query is checked
+func SearchInput(name string, label string) string {
+	data := struct {
+		Name    string
+		Label   string
+		Filters []alerts.SearchFilter
+	}{
+		Name:    name,
+		Label:   label,
+		Filters: alerts.SearchFilters,
+	}
+
+	const page = `
+	<details>
+		<summary>{{.Label}}</summary>
+		<div hx-post="/search/{{.Name}}" hx-trigger="input delay:500ms" hx-target="#{{.Name}}Query" hx-swap="innerHTML" hx-sync="this:replace" hx-push-url="false" hx-params="{{range $i, $filter := .Filters}}{{if $i}},{{end}}{{$.Name}}.{{$filter.Name}}{{end}}" hx-encoding="application/x-www-form-urlencoded" hx-indicator="#{{.Name}}Query">
+			{{range .Filters}}
+			<label>
+				{{.Label}} <small>{{if .List}}separated by commas or lines{{else}}one per line{{end}}</small>
+				<textarea name="{{$.Name}}.{{.Name}}" rows="2"></textarea>
+			</label>
+			{{end}}
+		</div>
+		<small id="{{.Name}}Query"></small>
+	</details>
+	`
+
+	return Execute("searchInput", page, data)
+}
+
+// SearchSummary shows the query the search fields build, or why they can't build one
+func SearchSummary(query alerts.Query, err error) string {
+	data := struct {
+		Query string
+		Err   error
+	}{
+		Query: query.String(),
+		Err:   err,
+	}
+
+	const page = `{{with .Err}}<span class="pico-color-red-500">{{.Error}}</span><br>{{end}}{{with .Query}}<code>{{.}}</code>{{end}}`
+
+	return Execute("searchSummary", page, data)
+}
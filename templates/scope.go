@@ -0,0 +1,36 @@
+package templates
+
+import (
+	"github.com/eagledb14/form-scanner/alerts"
+)
+
+// ScopeInput is an address field that checks what has been typed as it changes. The check
+// only sends this field, not the rest of the form it sits in or the export file uploaded with it,
+// and leaves the form's loading indicator alone
+func ScopeInput(name string, label string) string {
+	data := struct {
+		Name  string
+		Label string
+	}{
+		Name:  name,
+		Label: label,
+	}
+
+	const page = `
+	<label>
+		{{.Label}}
+		<input name="{{.Name}}" hx-post="/scope/{{.Name}}" hx-trigger="input changed delay:500ms" hx-target="#{{.Name}}Scope" hx-swap="innerHTML" hx-sync="this:replace" hx-push-url="false" hx-params="{{.Name}}" hx-encoding="application/x-www-form-urlencoded" hx-indicator="#{{.Name}}Scope"/>
+		<small id="{{.Name}}Scope"></small>
+	</label>
+	`
+
+	return Execute("scopeInput", page, data)
+}
+
+// ScopeSummary lists the tokens that couldn't be read, what each hostname resolved to and
+// counts the addresses of the tokens that could be read
+func ScopeSummary(scope alerts.Scope) string {
+	const page = `{{range .Errors}}<span class="pico-color-red-500">{{.Error}}</span><br>{{end}}{{range .Hosts}}{{.Host}} &rarr; {{range $i, $ip := .Ips}}{{if $i}}, {{end}}{{$ip}}{{end}}<br>{{end}}{{if .Ranges}}{{len .Ranges}} range(s), {{.Count}} address(es){{end}}`
+
+	return Execute("scopeSummary", page, scope)
+}
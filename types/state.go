@@ -1,13 +1,20 @@
 package types
 
 import (
-	"time"
+	"context"
+	"sync"
 
 	"github.com/eagledb14/form-scanner/alerts"
 )
 
 type State struct {
-    FeedEvents []*alerts.Event
+    Client alerts.ShodanClient
+    // the sqlite stores are opened once and shared by every request
+    Orgs *alerts.OrgStore
+    HostCache *alerts.HostCache
+    feedEvents []*alerts.Event
+    quarantined []alerts.QuarantinedItem
+    feedMu sync.RWMutex
     Events []*alerts.Event
     Name string
     EventIndex int
@@ -16,43 +23,84 @@ type State struct {
     Title string
     Tlp bool
     Report ReportType
+    Warning string
 }
 
-func NewState() *State {
+func NewState(client alerts.ShodanClient) *State {
 
     newState := &State{
+	Client: client,
+	Orgs: alerts.NewOrgStore(),
+	HostCache: alerts.NewHostCache(),
 	Tlp: true,
 	Report: Header,
     }
 
     go func(state *State) {
-	feedEvents := alerts.DownloadRss()
-	newState.FeedEvents = feedEvents
-	newState.LoadEvents()
+	state.SetFeedEvents(alerts.DownloadRss(state.Client))
     }(newState)
     return newState
 }
 
+// the feed is downloaded in the background, so it is only touched through these
+func (e *State) FeedEvents() []*alerts.Event {
+    e.feedMu.RLock()
+    defer e.feedMu.RUnlock()
+    return e.feedEvents
+}
+
+// feed items that could not be parsed
+func (e *State) Quarantined() []alerts.QuarantinedItem {
+    e.feedMu.RLock()
+    defer e.feedMu.RUnlock()
+    return e.quarantined
+}
+
+// replaces the feed and starts loading the new events
+func (e *State) SetFeedEvents(events []*alerts.Event, quarantined []alerts.QuarantinedItem) {
+    e.feedMu.Lock()
+    e.feedEvents = events
+    e.quarantined = quarantined
+    e.feedMu.Unlock()
+
+    e.LoadEvents()
+}
+
+// adds a streamed event to the end of the feed and starts loading it
+func (e *State) AddFeedEvent(event *alerts.Event) {
+    e.feedMu.Lock()
+    e.feedEvents = append(e.feedEvents, event)
+    e.feedMu.Unlock()
+
+    go event.Load(e.Client)
+}
+
+// keeps adding events from the shodan alert stream until the context ends
+func (e *State) Stream(ctx context.Context) {
+    go alerts.ConsumeStream(ctx, e.Client, e.AddFeedEvent)
+}
+
+// loads the feed events in the background, the shared limiter keeps it within the api rate
 func (e *State) LoadEvents() {
-    for _, e := range e.FeedEvents {
-	time.Sleep(time.Duration(1 * time.Second))
-	go func(e *alerts.Event) {
-	    e.Load()
-	}(e)
-    }
+    events := e.FeedEvents()
+    go alerts.SharedLimiter().ForEach(len(events), func(i int) {
+	events[i].Load(e.Client)
+    })
 }
 
 func (e *State) GetFeedEvent(index int) *alerts.Event {
+    feedEvents := e.FeedEvents()
+
     if index < 0 {
 	index = 0
-    } else if index >= len(e.FeedEvents) {
-	index = len(e.FeedEvents) - 1
+    } else if index >= len(feedEvents) {
+	index = len(feedEvents) - 1
     }
 
     pagedIndex := index + (e.EventIndex * 10)
-    if pagedIndex >= len(e.FeedEvents) {
-	pagedIndex = len(e.FeedEvents) - 1
+    if pagedIndex >= len(feedEvents) {
+	pagedIndex = len(feedEvents) - 1
     }
 
-    return e.FeedEvents[pagedIndex]
+    return feedEvents[pagedIndex]
 }
@@ -0,0 +1,68 @@
+package types
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+
+	"github.com/eagledb14/form-scanner/alerts"
+)
+
+// answers every host lookup with the same banner, every other call panics on the nil client
+type hostClient struct {
+	alerts.ShodanClient
+}
+
+func (h hostClient) Host(ip string) ([]byte, error) {
+	return []byte(`{"ports": [443], "data": [{"port": 443, "transport": "tcp", "product": "nginx"}]}`), nil
+}
+
+func newEvents(from int, count int) []*alerts.Event {
+	events := []*alerts.Event{}
+	for i := from; i < from+count; i++ {
+		events = append(events, alerts.NewEventFromIp(fmt.Sprintf("192.0.2.%d", i)))
+	}
+	return events
+}
+
+// the feed is replaced, added to, retried and read all at once, like the stream, the
+// feed download and the pages do
+func TestStateConcurrentFeed(t *testing.T) {
+	state := &State{Client: hostClient{}}
+	state.SetFeedEvents(newEvents(0, 10), nil)
+
+	wg := sync.WaitGroup{}
+	for i := 0; i < 10; i++ {
+		wg.Add(4)
+		go func() {
+			defer wg.Done()
+			state.AddFeedEvent(newEvents(100+i, 1)[0])
+		}()
+		go func() {
+			defer wg.Done()
+			if i%5 == 0 {
+				state.SetFeedEvents(newEvents(10*i, 10), nil)
+			}
+		}()
+		go func() {
+			defer wg.Done()
+			for _, event := range state.FeedEvents() {
+				event.Wait(context.Background())
+			}
+			state.GetFeedEvent(i).Loaded()
+		}()
+		go func() {
+			defer wg.Done()
+			state.GetFeedEvent(i).Retry(state.Client)
+		}()
+	}
+	wg.Wait()
+
+	for _, event := range state.FeedEvents() {
+		event.Wait(context.Background())
+		if len(event.Ports()) != 1 || event.Incomplete() {
+			t.Errorf("%s has ports %v and errors %v, want port 443", event.Ip, event.Ports(), event.Errors())
+		}
+	}
+}
@@ -0,0 +1,160 @@
+package types
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+	"text/template"
+)
+
+const defaultThreat = "T1133 External Remote Services"
+
+// TriggerForm is the form a shodan trigger opens and the text it starts with. Summary and
+// Body are text templates given .Name, .Ips and .Trigger, when empty the form's own text is used
+type TriggerForm struct {
+	Form    Form   `json:"form"`
+	Threat  string `json:"threat"`
+	Summary string `json:"summary"`
+	Body    string `json:"body"`
+}
+
+// the short names forms are given in the trigger config, matching their /event routes
+var formKeys = map[string]Form{
+	"open":  Open,
+	"eol":   EOL,
+	"login": Login,
+}
+
+func (f *Form) UnmarshalJSON(data []byte) error {
+	var key string
+	if err := json.Unmarshal(data, &key); err != nil {
+		return err
+	}
+
+	form, ok := formKeys[key]
+	if !ok {
+		return fmt.Errorf("unknown form %q, expected open, eol or login", key)
+	}
+	*f = form
+	return nil
+}
+
+// the form used when an analyst picks a form by hand
+func FormDefaults(form Form) TriggerForm {
+	return TriggerForm{
+		Form:   form,
+		Threat: defaultThreat,
+	}
+}
+
+// the mapping used when TRIGGER_FORMS is unset, "*" is used for triggers not listed
+func DefaultTriggerForms() map[string]TriggerForm {
+	return map[string]TriggerForm{
+		"*": FormDefaults(Open),
+		"end_of_life": {
+			Form:   EOL,
+			Threat: "T1190 Exploit Public-Facing Application",
+		},
+		"vulnerable": {
+			Form:   Open,
+			Threat: "T1190 Exploit Public-Facing Application",
+		},
+		"vulnerable_unverified": {
+			Form:   Open,
+			Threat: "T1190 Exploit Public-Facing Application",
+		},
+		"open_database": {
+			Form:    Open,
+			Threat:  "T1213 Data from Information Repositories",
+			Summary: "The North Carolina National Guard Cyber Security Response Force (NCNG CSRF) received an alert indicating the {{.Name}} domain is publicly exposing a database to the internet.",
+			Body:    "A threat actor may be able to read, alter or delete the data stored in a database that is reachable from the internet. We encourage {{.Name}} to review the infrastructure at the following IP addresses: {{.Ips}} and restrict database access to trusted networks. We also encourage {{.Name}} to search for indicators of unauthorized access because threat actors routinely search for exposed databases.",
+		},
+		"industrial_control_system": {
+			Form:    Open,
+			Threat:  "T0883 Internet Accessible Device",
+			Summary: "The North Carolina National Guard Cyber Security Response Force (NCNG CSRF) received an alert indicating the {{.Name}} domain is publicly exposing industrial control system services to the internet.",
+			Body:    "A threat actor may be able to read or change the state of physical processes through industrial control system services reachable from the internet. We encourage {{.Name}} to review the infrastructure at the following IP addresses: {{.Ips}} and place these services behind a VPN or firewall. We also encourage {{.Name}} to search for indicators of unauthorized access because threat actors actively target internet facing control systems.",
+		},
+	}
+}
+
+// LoadTriggerForms reads a trigger mapping from a json file
+func LoadTriggerForms(path string) (map[string]TriggerForm, error) {
+	body, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	forms := make(map[string]TriggerForm)
+	if err := json.Unmarshal(body, &forms); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+
+	for trigger, form := range forms {
+		if form.Threat == "" {
+			form.Threat = defaultThreat
+			forms[trigger] = form
+		}
+		for _, text := range []string{form.Summary, form.Body} {
+			if _, err := template.New(trigger).Parse(text); err != nil {
+				return nil, fmt.Errorf("%s: %s: %w", path, trigger, err)
+			}
+		}
+	}
+
+	if _, ok := forms["*"]; !ok {
+		forms["*"] = FormDefaults(Open)
+	}
+
+	return forms, nil
+}
+
+var (
+	triggerForms     map[string]TriggerForm
+	triggerFormsOnce sync.Once
+)
+
+// TriggerForms is the mapping named by TRIGGER_FORMS, or the default mapping when unset.
+// It is read on first use so the env file has been loaded by then
+func TriggerForms() map[string]TriggerForm {
+	triggerFormsOnce.Do(func() {
+		path := os.Getenv("TRIGGER_FORMS")
+		if path == "" {
+			triggerForms = DefaultTriggerForms()
+			return
+		}
+
+		forms, err := LoadTriggerForms(path)
+		if err != nil {
+			panic("error loading trigger forms: " + err.Error())
+		}
+		triggerForms = forms
+	})
+	return triggerForms
+}
+
+// FormForTrigger finds the form for a trigger. Events can carry several triggers joined
+// with commas, the first one with a mapping wins
+func FormForTrigger(trigger string) TriggerForm {
+	forms := TriggerForms()
+
+	for _, t := range strings.Split(trigger, ",") {
+		if form, ok := forms[strings.TrimSpace(t)]; ok {
+			return form
+		}
+	}
+
+	return forms["*"]
+}
+
+// the trigger's form when it is the one asked for, otherwise the defaults for the form asked for
+func FormForEvent(trigger string, form Form) TriggerForm {
+	triggerForm := FormForTrigger(trigger)
+	if triggerForm.Form == form {
+		return triggerForm
+	}
+
+	return FormDefaults(form)
+}
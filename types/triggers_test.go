@@ -0,0 +1,140 @@
+package types
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+)
+
+const testTriggerForms = `{
+	"*": {"form": "eol"},
+	"open_database": {"form": "open", "threat": "T1213 Data from Information Repositories", "summary": "{{.Name}} exposes {{.Trigger}}", "body": "{{.Name}} should review {{.Ips}}"},
+	"default_login": {"form": "login"}
+}`
+
+func writeTriggerForms(t *testing.T, body string) string {
+	t.Helper()
+
+	path := filepath.Join(t.TempDir(), "triggers.json")
+	if err := os.WriteFile(path, []byte(body), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+// points TRIGGER_FORMS at the mapping and reads it again, putting the default mapping
+// back once the test ends
+func useTriggerForms(t *testing.T, body string) {
+	t.Setenv("TRIGGER_FORMS", writeTriggerForms(t, body))
+	triggerFormsOnce = sync.Once{}
+	t.Cleanup(func() {
+		triggerFormsOnce = sync.Once{}
+		triggerForms = nil
+	})
+}
+
+func TestLoadTriggerForms(t *testing.T) {
+	forms, err := LoadTriggerForms(writeTriggerForms(t, testTriggerForms))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		trigger string
+		form    Form
+		threat  string
+	}{
+		{"*", EOL, defaultThreat},
+		{"open_database", Open, "T1213 Data from Information Repositories"},
+		// a trigger without a threat gets the default one
+		{"default_login", Login, defaultThreat},
+	}
+	for _, test := range tests {
+		form := forms[test.trigger]
+		if form.Form != test.form || form.Threat != test.threat {
+			t.Errorf("%s maps to %s %q, want %s %q", test.trigger, FormName[form.Form], form.Threat, FormName[test.form], test.threat)
+		}
+	}
+
+	// a mapping without "*" sends unlisted triggers to the open port form
+	forms, err = LoadTriggerForms(writeTriggerForms(t, `{"end_of_life": {"form": "eol"}}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if forms["*"] != FormDefaults(Open) {
+		t.Errorf("* maps to %v, want the open port defaults", forms["*"])
+	}
+}
+
+func TestLoadTriggerFormsInvalid(t *testing.T) {
+	tests := []struct {
+		name   string
+		body   string
+		reason string
+	}{
+		{"unknown form", `{"open_database": {"form": "report"}}`, `unknown form "report"`},
+		{"summary that fails to parse", `{"open_database": {"form": "open", "summary": "{{.Name"}}`, "open_database"},
+		{"body that fails to parse", `{"open_database": {"form": "open", "body": "{{end}}"}}`, "open_database"},
+		{"not json", `open_database: open`, "invalid character"},
+	}
+
+	for _, test := range tests {
+		_, err := LoadTriggerForms(writeTriggerForms(t, test.body))
+		if err == nil || !strings.Contains(err.Error(), test.reason) {
+			t.Errorf("%s: got error %v, want %q", test.name, err, test.reason)
+		}
+	}
+
+	if _, err := LoadTriggerForms(filepath.Join(t.TempDir(), "missing.json")); err == nil {
+		t.Error("loaded a mapping that does not exist")
+	}
+}
+
+func TestFormForTrigger(t *testing.T) {
+	useTriggerForms(t, testTriggerForms)
+	forms := TriggerForms()
+
+	tests := []struct {
+		trigger string
+		want    string
+	}{
+		{"open_database", "open_database"},
+		{"default_login", "default_login"},
+		// triggers not in the mapping use "*"
+		{"ssl_expired", "*"},
+		{"", "*"},
+		// the first of several triggers that has a mapping wins
+		{"ssl_expired, default_login", "default_login"},
+		{"open_database,default_login", "open_database"},
+		{"default_login, open_database", "default_login"},
+	}
+	for _, test := range tests {
+		if form := FormForTrigger(test.trigger); form != forms[test.want] {
+			t.Errorf("%q maps to %v, want the %s mapping %v", test.trigger, form, test.want, forms[test.want])
+		}
+	}
+}
+
+func TestFormForEvent(t *testing.T) {
+	useTriggerForms(t, testTriggerForms)
+	forms := TriggerForms()
+
+	tests := []struct {
+		trigger string
+		form    Form
+		want    TriggerForm
+	}{
+		{"open_database", Open, forms["open_database"]},
+		{"ssl_expired", EOL, forms["*"]},
+		// picking another form than the trigger's gives that form's defaults
+		{"open_database", Login, FormDefaults(Login)},
+		{"ssl_expired", Open, FormDefaults(Open)},
+	}
+	for _, test := range tests {
+		if form := FormForEvent(test.trigger, test.form); form != test.want {
+			t.Errorf("%q as %s is %v, want %v", test.trigger, FormName[test.form], form, test.want)
+		}
+	}
+}